@@ -1,7 +1,28 @@
 package gokounta
 
+import (
+	"errors"
+	"fmt"
+)
+
 // TokenResponse is the response for requesting a token
 type TokenResponse struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
 }
+
+//NotFoundError is returned when Kounta has no customer or product with the id asked for
+type NotFoundError struct {
+	Resource string
+	Status   string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("Failed to get Kounta %s %s", e.Resource, e.Status)
+}
+
+// IsNotFound will return true when the error is a NotFoundError
+func IsNotFound(err error) bool {
+	var nf *NotFoundError
+	return errors.As(err, &nf)
+}
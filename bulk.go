@@ -0,0 +1,226 @@
+package gokounta
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	defaultBulkConcurrency = 4
+)
+
+//BulkTarget is one company site to fetch orders for, targets may span many companies each with their own token
+type BulkTarget struct {
+	Token   string
+	Company string
+	SiteID  string
+}
+
+//BulkResult is the orders fetched for one target, or the error fetching them
+type BulkResult struct {
+	Target BulkTarget
+	Orders []Order
+	Err    error
+}
+
+//MultiError collects the errors of a bulk fetch
+type MultiError []error
+
+func (m MultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strconv.Itoa(len(m)) + " Kounta requests failed: " + strings.Join(msgs, "; ")
+}
+
+//CompanyRegistry holds the access token of each company a bulk fetch spans
+type CompanyRegistry struct {
+	mu     sync.RWMutex
+	tokens map[string]string
+}
+
+// NewCompanyRegistry will create an empty CompanyRegistry
+func NewCompanyRegistry() *CompanyRegistry {
+	return &CompanyRegistry{tokens: map[string]string{}}
+}
+
+// Set will add a company or replace its access token, for use after a refresh
+func (r *CompanyRegistry) Set(company string, token string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens[company] = token
+}
+
+// Remove will drop a company from the registry
+func (r *CompanyRegistry) Remove(company string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.tokens, company)
+}
+
+// Token will return the access token of a company
+func (r *CompanyRegistry) Token(company string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	token, ok := r.tokens[company]
+	return token, ok
+}
+
+// Companies will return the ids of the registered companies in order
+func (r *CompanyRegistry) Companies() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	companies := make([]string, 0, len(r.tokens))
+	for company := range r.tokens {
+		companies = append(companies, company)
+	}
+	sort.Strings(companies)
+	return companies
+}
+
+//BulkFetcher fetches orders for many sites and companies at once, with requests paced by the client's Limiter
+type BulkFetcher struct {
+	Client *Kounta
+	// Concurrency is how many sites are fetched at the same time
+	Concurrency int
+}
+
+// NewBulkFetcher will create a BulkFetcher with the default concurrency
+func NewBulkFetcher(client *Kounta) *BulkFetcher {
+	return &BulkFetcher{
+		Client:      client,
+		Concurrency: defaultBulkConcurrency,
+	}
+}
+
+// RegistryTargets will list the sites of every company in the registry and return a target for each site.
+// Companies whose sites could not be listed are returned in a MultiError along with the targets that could.
+func (b *BulkFetcher) RegistryTargets(ctx context.Context, registry *CompanyRegistry) ([]BulkTarget, error) {
+	targets := []BulkTarget{}
+	var errs MultiError
+
+	for _, company := range registry.Companies() {
+		if err := ctx.Err(); err != nil {
+			return targets, err
+		}
+
+		token, ok := registry.Token(company)
+		if !ok {
+			continue
+		}
+		sites, err := b.Client.GetSites(token, company)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("company %v: %v", company, err))
+			continue
+		}
+		targets = append(targets, SiteTargets(token, company, sites)...)
+	}
+
+	if len(errs) > 0 {
+		return targets, errs
+	}
+	return targets, nil
+}
+
+// SiteTargets will return a target for each site of a company
+func SiteTargets(token string, company string, sites Sites) []BulkTarget {
+	targets := make([]BulkTarget, 0, len(sites))
+	for _, s := range sites {
+		targets = append(targets, BulkTarget{Token: token, Company: company, SiteID: strconv.Itoa(s.ID)})
+	}
+	return targets
+}
+
+// CompleteOrders will fetch the completed orders of every target, sending results as each one arrives.
+// The channel is closed once every target has a result, see Fetch for what happens when the context is cancelled.
+func (b *BulkFetcher) CompleteOrders(ctx context.Context, targets []BulkTarget, query OrderQuery) <-chan BulkResult {
+	return b.Fetch(ctx, targets, func(ctx context.Context, t BulkTarget) ([]Order, error) {
+		return b.Client.QueryCompleteOrdersContext(ctx, t.Token, t.Company, t.SiteID, query)
+	})
+}
+
+// PendingOrders will fetch the pending orders of every target, sending results as each one arrives
+func (b *BulkFetcher) PendingOrders(ctx context.Context, targets []BulkTarget, query OrderQuery) <-chan BulkResult {
+	return b.Fetch(ctx, targets, func(ctx context.Context, t BulkTarget) ([]Order, error) {
+		return b.Client.QueryPendingOrdersContext(ctx, t.Token, t.Company, t.SiteID, query)
+	})
+}
+
+// Fetch will call fetch for every target with bounded concurrency, sending results as each one arrives.
+// The context is passed to fetch so cancelling it also stops the requests in flight, and every target
+// not fetched by then is sent with the context's error, so there is always one result per target.
+// The channel is buffered for every target, so results are never lost if the reader stops early.
+func (b *BulkFetcher) Fetch(ctx context.Context, targets []BulkTarget, fetch func(context.Context, BulkTarget) ([]Order, error)) <-chan BulkResult {
+	concurrency := b.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultBulkConcurrency
+	}
+
+	results := make(chan BulkResult, len(targets))
+	jobs := make(chan BulkTarget)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range jobs {
+				if err := ctx.Err(); err != nil {
+					results <- BulkResult{Target: t, Err: err}
+					continue
+				}
+				orders, err := fetch(ctx, t)
+				results <- BulkResult{Target: t, Orders: orders, Err: err}
+			}
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(jobs)
+		for i, t := range targets {
+			select {
+			case jobs <- t:
+			case <-ctx.Done():
+				for _, t := range targets[i:] {
+					results <- BulkResult{Target: t, Err: ctx.Err()}
+				}
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
+// Collect will gather the orders of every result, returning a MultiError for the targets that failed.
+// Targets that were not fetched because the context was cancelled are in the MultiError with the context's error.
+func Collect(results <-chan BulkResult) ([]Order, error) {
+	orders := []Order{}
+	var errs MultiError
+
+	for r := range results {
+		if r.Err != nil {
+			errs = append(errs, fmt.Errorf("company %v site %v: %w", r.Target.Company, r.Target.SiteID, r.Err))
+			continue
+		}
+		orders = append(orders, r.Orders...)
+	}
+
+	if len(errs) > 0 {
+		return orders, errs
+	}
+	return orders, nil
+}
@@ -0,0 +1,51 @@
+package gokounta
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestCollectCancelledMidFetch(t *testing.T) {
+	targets := make([]BulkTarget, 6)
+	for i := range targets {
+		targets[i] = BulkTarget{Company: "1", SiteID: strconv.Itoa(i)}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the first site is fetched, the second cancels while its request is in flight
+	fetch := func(ctx context.Context, t BulkTarget) ([]Order, error) {
+		switch t.SiteID {
+		case "0":
+			return []Order{{ID: 1}}, nil
+		case "1":
+			cancel()
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return []Order{{ID: 2}}, nil
+	}
+
+	b := &BulkFetcher{Concurrency: 1}
+	orders, err := Collect(b.Fetch(ctx, targets, fetch))
+
+	if len(orders) != 1 || orders[0].ID != 1 {
+		t.Errorf("orders %+v, want only the order fetched before the cancel", orders)
+	}
+
+	var errs MultiError
+	if !errors.As(err, &errs) {
+		t.Fatalf("Collect error %v, want a MultiError", err)
+	}
+	if len(errs) != len(targets)-1 {
+		t.Errorf("%d errors, want one for each of the %d sites not fetched: %v", len(errs), len(targets)-1, errs)
+	}
+	for _, e := range errs {
+		if !errors.Is(e, context.Canceled) {
+			t.Errorf("error %v, want %v", e, context.Canceled)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package gokounta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cached resources, used for TTLs and invalidation
+const (
+	ResourceSites          = "sites"
+	ResourceCategories     = "categories"
+	ResourceProducts       = "products"
+	ResourceStaff          = "staff"
+	ResourcePaymentMethods = "payment_methods"
+	ResourceTaxes          = "taxes"
+	ResourceCustomers      = "customers"
+)
+
+var (
+	defaultCacheTTL = time.Minute * 15
+)
+
+// webHookResources maps the first part of a webhook topic to the cached resource it changes
+var webHookResources = map[string]string{
+	"sites":           ResourceSites,
+	"categories":      ResourceCategories,
+	"products":        ResourceProducts,
+	"staff":           ResourceStaff,
+	"staff_members":   ResourceStaff,
+	"payment_methods": ResourcePaymentMethods,
+	"taxes":           ResourceTaxes,
+	"customers":       ResourceCustomers,
+}
+
+//CacheEntry is a cached Kounta response body with the ETag it was served with
+type CacheEntry struct {
+	Body     []byte
+	ETag     string
+	StoredAt time.Time
+}
+
+//CacheBackend stores cached Kounta responses, implement it to share a cache such as redis between processes
+type CacheBackend interface {
+	Get(key string) (CacheEntry, bool)
+	Set(key string, entry CacheEntry)
+	Delete(key string)
+	DeletePrefix(prefix string)
+}
+
+//MemoryCache is a CacheBackend held in memory
+type MemoryCache struct {
+	mu      sync.RWMutex
+	entries map[string]CacheEntry
+}
+
+// NewMemoryCache will create an empty MemoryCache
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: map[string]CacheEntry{}}
+}
+
+// Get will return a cached entry
+func (c *MemoryCache) Get(key string) (CacheEntry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, ok := c.entries[key]
+	return e, ok
+}
+
+// Set will store an entry
+func (c *MemoryCache) Set(key string, entry CacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = entry
+}
+
+// Delete will remove an entry
+func (c *MemoryCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+// DeletePrefix will remove every entry whose key starts with the prefix
+func (c *MemoryCache) DeletePrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k := range c.entries {
+		if strings.HasPrefix(k, prefix) {
+			delete(c.entries, k)
+		}
+	}
+}
+
+//CachingClient is a Kounta client that caches reference data, other calls go straight to the embedded client
+type CachingClient struct {
+	*Kounta
+
+	Backend    CacheBackend
+	DefaultTTL time.Duration
+	// TTLs overrides the DefaultTTL for a resource such as ResourceSites
+	TTLs map[string]time.Duration
+}
+
+// NewCachingClient will create a CachingClient in front of the client, using a MemoryCache when backend is nil
+func NewCachingClient(client *Kounta, backend CacheBackend) *CachingClient {
+	if backend == nil {
+		backend = NewMemoryCache()
+	}
+	return &CachingClient{
+		Kounta:     client,
+		Backend:    backend,
+		DefaultTTL: defaultCacheTTL,
+		TTLs:       map[string]time.Duration{},
+	}
+}
+
+// GetSites will return the sites of the company from the cache when fresh
+func (c *CachingClient) GetSites(token string, company string) (Sites, error) {
+	var resp Sites
+	info := RequestInfo{Endpoint: "GetSites", Company: company}
+	err := c.cachedGet(info, token, ResourceSites, "", c.endpoint(fmt.Sprintf(sitesURL, company)), &resp)
+	return resp, err
+}
+
+// GetCategories will return the categories of the company from the cache when fresh
+func (c *CachingClient) GetCategories(token string, company string) (Categories, error) {
+	var resp Categories
+	info := RequestInfo{Endpoint: "GetCategories", Company: company}
+	err := c.cachedGet(info, token, ResourceCategories, "", c.endpoint(fmt.Sprintf(categoriesURL, company)), &resp)
+	return resp, err
+}
+
+// GetStaff will return the staff of the company from the cache when fresh
+func (c *CachingClient) GetStaff(token string, company string) (Staffs, error) {
+	var resp Staffs
+	info := RequestInfo{Endpoint: "GetStaff", Company: company}
+	err := c.cachedGet(info, token, ResourceStaff, "", c.endpoint(fmt.Sprintf(staffURL, company)), &resp)
+	return resp, err
+}
+
+// ListPaymentMethods will return the payment methods of the company from the cache when fresh
+func (c *CachingClient) ListPaymentMethods(token string, company string) (PaymentMethods, error) {
+	var resp PaymentMethods
+	info := RequestInfo{Endpoint: "ListPaymentMethods", Company: company}
+	err := c.cachedGet(info, token, ResourcePaymentMethods, "", c.endpoint(fmt.Sprintf(paymentMethodsURL, company)), &resp)
+	return resp, err
+}
+
+// ListTaxes will return the taxes of the company from the cache when fresh
+func (c *CachingClient) ListTaxes(token string, company string) (Taxes, error) {
+	var resp Taxes
+	info := RequestInfo{Endpoint: "ListTaxes", Company: company}
+	err := c.cachedGet(info, token, ResourceTaxes, "", c.endpoint(fmt.Sprintf(taxesURL, company)), &resp)
+	return resp, err
+}
+
+// GetCustomer will return a customer of the company from the cache when fresh
+func (c *CachingClient) GetCustomer(token string, company string, customerID int64) (*Customer, error) {
+	var resp Customer
+	info := RequestInfo{Endpoint: "GetCustomer", Company: company}
+	id := strconv.FormatInt(customerID, 10)
+	if err := c.cachedGet(info, token, ResourceCustomers, id, c.endpoint(fmt.Sprintf(customerSingleURL, company, customerID)), &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetProduct will return a single product of the company from the cache when fresh
+func (c *CachingClient) GetProduct(token string, company string, productID int64) (*KountaProduct, error) {
+	var resp KountaProduct
+	info := RequestInfo{Endpoint: "GetProduct", Company: company}
+	// single products share the products resource with the category lists so product webhooks drop both
+	id := "id-" + strconv.FormatInt(productID, 10)
+	if err := c.cachedGet(info, token, ResourceProducts, id, c.endpoint(fmt.Sprintf(productSingleURL, company, productID)), &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetProducts will return every page of products in a category from the cache when fresh
+func (c *CachingClient) GetProducts(token string, company string, categoryID string) (KountaProducts, error) {
+	key := c.key(company, ResourceProducts, categoryID)
+
+	var resp KountaProducts
+	if entry, ok := c.Backend.Get(key); ok && c.fresh(ResourceProducts, entry) {
+		if err := json.Unmarshal(entry.Body, &resp); err == nil {
+			return resp, nil
+		}
+	}
+
+	resp, err := c.Kounta.GetProducts(token, company, categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if b, err := json.Marshal(resp); err == nil {
+		c.Backend.Set(key, CacheEntry{Body: b, StoredAt: time.Now()})
+	}
+	return resp, nil
+}
+
+// Invalidate will drop the cached copies of a resource for a company
+func (c *CachingClient) Invalidate(company string, resource string) {
+	c.Backend.DeletePrefix(c.key(company, resource, ""))
+}
+
+// InvalidateWebHook will drop the cached resource changed by a webhook topic such as products/updated
+func (c *CachingClient) InvalidateWebHook(company string, topic string) {
+	name := strings.SplitN(topic, "/", 2)[0]
+	if resource, ok := webHookResources[name]; ok {
+		c.Invalidate(company, resource)
+	}
+}
+
+// cachedGet will serve a resource from the cache while fresh, revalidating stale entries with If-None-Match
+func (c *CachingClient) cachedGet(info RequestInfo, token string, resource string, id string, urlStr string, out interface{}) error {
+	company := info.Company
+	key := c.key(company, resource, id)
+
+	entry, cached := c.Backend.Get(key)
+	if cached && c.fresh(resource, entry) {
+		if err := json.Unmarshal(entry.Body, out); err == nil {
+			return nil
+		}
+		c.Backend.Delete(key)
+		cached = false
+	}
+
+	r, err := c.newRequest(context.Background(), info, "GET", token, urlStr, nil)
+	if err != nil {
+		return err
+	}
+	if cached && entry.ETag != "" {
+		r.Header.Set("If-None-Match", entry.ETag)
+	}
+
+	res, rawResBody, err := c.send(r)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case res.StatusCode == http.StatusNotModified && cached:
+		entry.StoredAt = time.Now()
+	case res.StatusCode == 200:
+		entry = CacheEntry{Body: rawResBody, ETag: res.Header.Get("ETag"), StoredAt: time.Now()}
+	case res.StatusCode == http.StatusNotFound:
+		c.Backend.Delete(key)
+		return &NotFoundError{Resource: resource, Status: res.Status}
+	default:
+		return fmt.Errorf("Failed to get Kounta %s %s", resource, res.Status)
+	}
+
+	if err := json.Unmarshal(entry.Body, out); err != nil {
+		c.Backend.Delete(key)
+		return err
+	}
+
+	c.Backend.Set(key, entry)
+	return nil
+}
+
+func (c *CachingClient) fresh(resource string, entry CacheEntry) bool {
+	ttl, ok := c.TTLs[resource]
+	if !ok {
+		ttl = c.DefaultTTL
+	}
+	return time.Since(entry.StoredAt) < ttl
+}
+
+func (c *CachingClient) key(company string, resource string, id string) string {
+	return "kounta:" + company + ":" + resource + ":" + id
+}
@@ -2,8 +2,68 @@ package gokounta
 
 //Company is the struct for a Kounta company
 type Company struct {
-	ID        int    `json:"id"`
-	Name      string `json:"name"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	ID             int                 `json:"id"`
+	Name           string              `json:"name"`
+	BusinessName   string              `json:"business_name"`
+	BusinessNumber string              `json:"business_number"`
+	Email          string              `json:"email"`
+	Phone          string              `json:"phone"`
+	Website        string              `json:"website"`
+	Currency       Currency            `json:"currency"`
+	Timezone       string              `json:"timezone"`
+	Address        CompanyAddress      `json:"address"`
+	Subscription   CompanySubscription `json:"subscription"`
+	CreatedAt      Timestamp           `json:"created_at"`
+	UpdatedAt      Timestamp           `json:"updated_at"`
+}
+
+//CompanyAddress is the address of a Kounta company
+type CompanyAddress struct {
+	Address1 string `json:"address"`
+	Address2 string `json:"address2"`
+	City     string `json:"city"`
+	State    string `json:"state"`
+	Postcode string `json:"postal_code"`
+	Country  string `json:"country"`
+}
+
+//CompanySubscription is the Kounta plan a company is on
+type CompanySubscription struct {
+	Plan      string    `json:"plan"`
+	Status    string    `json:"status"`
+	ExpiresAt Timestamp `json:"expires_at"`
+}
+
+//CompanyStatus is the account status of a Kounta company
+type CompanyStatus struct {
+	State     string   `json:"status"`
+	Plan      string   `json:"plan"`
+	Suspended bool     `json:"suspended"`
+	Features  []string `json:"features"`
+}
+
+// Company account states
+const (
+	CompanyStateActive    = "active"
+	CompanyStateTrial     = "trial"
+	CompanyStateSuspended = "suspended"
+	CompanyStateCancelled = "cancelled"
+)
+
+// IsActive will return true when the company can trade and is not suspended
+func (s *CompanyStatus) IsActive() bool {
+	if s.Suspended {
+		return false
+	}
+	return s.State == CompanyStateActive || s.State == CompanyStateTrial
+}
+
+// HasFeature will return true when the company has a feature enabled
+func (s *CompanyStatus) HasFeature(feature string) bool {
+	for _, f := range s.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,14 @@
+package gokounta
+
+//Customer is the struct for a Kounta customer
+type Customer struct {
+	ID        int64     `json:"id"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	Email     string    `json:"primary_email_address"`
+	Phone     string    `json:"phone"`
+	Mobile    string    `json:"mobile"`
+	Reference string    `json:"reference_id"`
+	CreatedAt Timestamp `json:"created_at"`
+	UpdatedAt Timestamp `json:"updated_at"`
+}
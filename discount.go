@@ -0,0 +1,99 @@
+package gokounta
+
+//LineAllocation is the final amounts of an order line once line and order price variations are applied
+type LineAllocation struct {
+	// Line is the index of the line in Order.Items
+	Line int
+	// List is the gross of the line and its modifiers before any price variation
+	List Money
+	// LineVariation is the change made by the line's own price variation, negative for a discount
+	LineVariation Money
+	// OrderVariation is the line's share of the order price variation, negative for a discount
+	OrderVariation Money
+	Gross          Money
+	Net            Money
+	Tax            Money
+	// Discount and Surcharge are negative on refund lines so they net against sales
+	Discount  Money
+	Surcharge Money
+}
+
+// hasVariation will return true when a price variation changes the price, 0 and 1 leave it unchanged
+func hasVariation(pv float64) bool {
+	return pv > 0 && pv != 1
+}
+
+// ListGross will return the gross of the line and its modifiers before any price variation.
+// modifierPrices holds the gross unit price of each modifier id and may be nil.
+func (oi *OrderLine) ListGross(modifierPrices map[int]Money) Money {
+	gross := oi.LineTotal + oi.LineTotalTax
+	for _, id := range oi.Modifiers {
+		gross += modifierPrices[id].Mul(oi.Quantity)
+	}
+	return gross
+}
+
+// AllocateVariations will apply the line price variations, then spread the order price variation
+// across the lines in proportion to their value. Discounts (variation below 1) and surcharges
+// (variation above 1) are both supported. Every amount is rounded to the minor units of the currency
+// and the allocated gross always sums exactly to Order.Total rounded to the currency.
+// When Order.Total is zero the total is worked out from the order price variation instead.
+func (order *Order) AllocateVariations(modifierPrices map[int]Money, currency Currency) []LineAllocation {
+	allocs := make([]LineAllocation, len(order.Items))
+	weights := make([]Money, len(order.Items))
+	subtotal := Money(0)
+
+	for i, item := range order.Items {
+		list := item.ListGross(modifierPrices)
+		gross := list
+		if hasVariation(item.PriceVariation) {
+			gross = list.Mul(item.PriceVariation)
+		}
+		gross = gross.Round(currency)
+
+		allocs[i] = LineAllocation{
+			Line:          i,
+			List:          list,
+			LineVariation: gross - list,
+		}
+		weights[i] = gross
+		subtotal += gross
+	}
+
+	target := order.Total
+	if target == 0 {
+		target = subtotal
+		if hasVariation(order.PriceVariation) {
+			target = subtotal.Mul(order.PriceVariation)
+		}
+	}
+	target = target.Round(currency)
+
+	shares := (target - subtotal).Allocate(weights, currency)
+
+	for i, item := range order.Items {
+		a := &allocs[i]
+		a.OrderVariation = shares[i]
+		a.Gross = weights[i] + shares[i]
+
+		// split the gross using the line's own tax ratio so rounding stays on the line
+		a.Tax = a.Gross.MulFrac(item.LineTotalTax, item.LineTotal+item.LineTotalTax).Round(currency)
+		a.Net = a.Gross - a.Tax
+
+		// measure the change against the direction of the line so refunds reverse their discount
+		change := a.LineVariation + a.OrderVariation
+		if a.List < 0 {
+			change = -change
+		}
+		if change < 0 {
+			a.Discount = -change
+		} else {
+			a.Surcharge = change
+		}
+		if a.List < 0 {
+			a.Discount, a.Surcharge = -a.Discount, -a.Surcharge
+		}
+	}
+
+	return allocs
+}
@@ -0,0 +1,158 @@
+package gokounta
+
+import "testing"
+
+func money(f float64) Money {
+	return MoneyFromFloat(f)
+}
+
+func TestMoneyAllocate(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   Money
+		weights  []Money
+		currency Currency
+		want     []Money
+	}{
+		{
+			name:     "equal thirds",
+			amount:   money(10),
+			weights:  []Money{money(1), money(1), money(1)},
+			currency: "AUD",
+			want:     []Money{money(3.34), money(3.33), money(3.33)},
+		},
+		{
+			name:     "negative thirds",
+			amount:   money(-10),
+			weights:  []Money{money(1), money(1), money(1)},
+			currency: "AUD",
+			want:     []Money{money(-3.34), money(-3.33), money(-3.33)},
+		},
+		{
+			name:     "proportional",
+			amount:   money(1),
+			weights:  []Money{money(20), money(5), money(0)},
+			currency: "AUD",
+			want:     []Money{money(0.8), money(0.2), 0},
+		},
+		{
+			name:     "zero weights split evenly",
+			amount:   money(0.05),
+			weights:  []Money{0, 0},
+			currency: "AUD",
+			want:     []Money{money(0.03), money(0.02)},
+		},
+		{
+			name:     "whole yen",
+			amount:   money(100),
+			weights:  []Money{money(1), money(1), money(1)},
+			currency: "JPY",
+			want:     []Money{money(34), money(33), money(33)},
+		},
+		{
+			name:     "amount rounded to currency first",
+			amount:   money(10.004),
+			weights:  []Money{money(1), money(1)},
+			currency: "AUD",
+			want:     []Money{money(5), money(5)},
+		},
+	}
+
+	for _, tt := range tests {
+		got := tt.amount.Allocate(tt.weights, tt.currency)
+		if len(got) != len(tt.want) {
+			t.Fatalf("%s: got %d parts, want %d", tt.name, len(got), len(tt.want))
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: part %d = %v, want %v", tt.name, i, got[i], tt.want[i])
+			}
+			if got[i] != got[i].Round(tt.currency) {
+				t.Errorf("%s: part %d = %v is not in minor units", tt.name, i, got[i])
+			}
+		}
+		if sum := SumMoney(got...); sum != tt.amount.Round(tt.currency) {
+			t.Errorf("%s: parts sum to %v, want %v", tt.name, sum, tt.amount.Round(tt.currency))
+		}
+	}
+}
+
+func TestAllocateVariations(t *testing.T) {
+	line := func(net, tax, pv float64) OrderLine {
+		return OrderLine{LineTotal: money(net), LineTotalTax: money(tax), Quantity: 1, PriceVariation: pv}
+	}
+
+	tests := []struct {
+		name      string
+		order     Order
+		gross     []Money
+		discounts []Money
+	}{
+		{
+			name:      "order discount over equal lines",
+			order:     Order{Total: money(10), Items: []OrderLine{line(4, 0, 0), line(4, 0, 0), line(4, 0, 0)}},
+			gross:     []Money{money(3.33), money(3.33), money(3.34)},
+			discounts: []Money{money(0.67), money(0.67), money(0.66)},
+		},
+		{
+			name:      "line discount matches Discount",
+			order:     Order{Total: money(9), Items: []OrderLine{line(9.09, 0.91, 0.9)}},
+			gross:     []Money{money(9)},
+			discounts: []Money{money(1)},
+		},
+		{
+			name:      "surcharge",
+			order:     Order{Total: money(11), Items: []OrderLine{line(10, 0, 0)}},
+			gross:     []Money{money(11)},
+			discounts: []Money{0},
+		},
+		{
+			name:      "refund line discount is negative",
+			order:     Order{Total: money(-9), Items: []OrderLine{line(-9.09, -0.91, 0.9)}},
+			gross:     []Money{money(-9)},
+			discounts: []Money{money(-1)},
+		},
+		{
+			name:      "total worked out from order variation",
+			order:     Order{PriceVariation: 0.5, Items: []OrderLine{line(3, 0, 0), line(2, 0, 0)}},
+			gross:     []Money{money(1.5), money(1)},
+			discounts: []Money{money(1.5), money(1)},
+		},
+	}
+
+	for _, tt := range tests {
+		allocs := tt.order.AllocateVariations(nil, "AUD")
+
+		sum := Money(0)
+		for i, a := range allocs {
+			sum += a.Gross
+			if a.Gross != tt.gross[i] {
+				t.Errorf("%s: line %d gross %v, want %v", tt.name, i, a.Gross, tt.gross[i])
+			}
+			if a.Discount != tt.discounts[i] {
+				t.Errorf("%s: line %d discount %v, want %v", tt.name, i, a.Discount, tt.discounts[i])
+			}
+			if a.Net+a.Tax != a.Gross || a.Tax != a.Tax.Round("AUD") {
+				t.Errorf("%s: line %d net %v tax %v do not split gross %v in cents", tt.name, i, a.Net, a.Tax, a.Gross)
+			}
+		}
+		if tt.order.Total != 0 && sum != tt.order.Total {
+			t.Errorf("%s: gross sums to %v, want %v", tt.name, sum, tt.order.Total)
+		}
+	}
+}
+
+func TestLineDiscountAgreesWithAllocation(t *testing.T) {
+	item := OrderLine{LineTotal: money(9.09), LineTotalTax: money(0.91), Quantity: 1, PriceVariation: 0.9}
+	order := Order{Items: []OrderLine{item}}
+
+	allocs := order.AllocateVariations(nil, "AUD")
+	if got := item.Discount(); got != allocs[0].Discount {
+		t.Errorf("Discount() = %v, allocation discount = %v", got, allocs[0].Discount)
+	}
+	// CalculateDiscount keeps the original after-variation convention
+	gross := 10.0
+	if got, want := item.CalculateDiscount(), gross/0.9-gross; got != want {
+		t.Errorf("CalculateDiscount() = %v, want %v", got, want)
+	}
+}
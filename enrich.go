@@ -0,0 +1,281 @@
+package gokounta
+
+import "sync"
+
+var (
+	defaultEnrichConcurrency = 4
+)
+
+//EnrichmentSource is the lookups an Enricher needs, satisfied by both Kounta and CachingClient
+type EnrichmentSource interface {
+	GetSites(token string, company string) (Sites, error)
+	GetCategories(token string, company string) (Categories, error)
+	GetStaff(token string, company string) (Staffs, error)
+	ListPaymentMethods(token string, company string) (PaymentMethods, error)
+	GetCustomer(token string, company string, customerID int64) (*Customer, error)
+	GetProduct(token string, company string, productID int64) (*KountaProduct, error)
+}
+
+//EnrichedOrder is an order with the full entities it refers to, nil where they could not be found
+type EnrichedOrder struct {
+	Order    Order
+	Site     *Site
+	Customer *Customer
+	Staff    *Staff
+	Lines    []EnrichedOrderLine
+	Payments []EnrichedOrderPayment
+}
+
+//EnrichedOrderLine is an order line with its product and the product's first category
+type EnrichedOrderLine struct {
+	Line     OrderLine
+	Product  *KountaProduct
+	Category *Category
+}
+
+//EnrichedOrderPayment is an order payment with its full payment method
+type EnrichedOrderPayment struct {
+	Payment OrderPayment
+	Method  *PaymentMethod
+}
+
+//Enricher resolves the entities of orders for one company, loading each entity once
+type Enricher struct {
+	Source  EnrichmentSource
+	Token   string
+	Company string
+	// Concurrency is how many customers and products are fetched at the same time
+	Concurrency int
+
+	mu         sync.Mutex
+	loaded     bool
+	sites      map[int64]Site
+	categories map[int]Category
+	staff      map[int]Staff
+	methods    map[int64]PaymentMethod
+	customers  map[int64]*Customer
+	products   map[int64]*KountaProduct
+}
+
+// NewEnricher will create an Enricher, pass a CachingClient as the source to share lookups between enrichers
+func NewEnricher(source EnrichmentSource, token string, company string) *Enricher {
+	return &Enricher{
+		Source:      source,
+		Token:       token,
+		Company:     company,
+		Concurrency: defaultEnrichConcurrency,
+		customers:   map[int64]*Customer{},
+		products:    map[int64]*KountaProduct{},
+	}
+}
+
+// EnrichOrder will resolve the entities of an order with the client
+func (v *Kounta) EnrichOrder(token string, company string, order Order) (*EnrichedOrder, error) {
+	return NewEnricher(v, token, company).EnrichOrder(order)
+}
+
+// EnrichOrders will resolve the entities of many orders with the client, looking up each entity once
+func (v *Kounta) EnrichOrders(token string, company string, orders []Order) ([]EnrichedOrder, error) {
+	return NewEnricher(v, token, company).EnrichOrders(orders)
+}
+
+// EnrichOrder will resolve the entities of an order
+func (e *Enricher) EnrichOrder(order Order) (*EnrichedOrder, error) {
+	enriched, err := e.EnrichOrders([]Order{order})
+	if err != nil {
+		return nil, err
+	}
+	return &enriched[0], nil
+}
+
+// EnrichOrders will resolve the entities of the orders, fetching each customer and product once for the batch.
+// Customers and products Kounta no longer has are left nil rather than failing the batch.
+func (e *Enricher) EnrichOrders(orders []Order) ([]EnrichedOrder, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.loadReference(); err != nil {
+		return nil, err
+	}
+
+	if err := e.loadEntities(orders); err != nil {
+		return nil, err
+	}
+
+	enriched := make([]EnrichedOrder, 0, len(orders))
+	for _, order := range orders {
+		enriched = append(enriched, e.enrich(order))
+	}
+	return enriched, nil
+}
+
+// loadReference will fetch the company wide lists once
+func (e *Enricher) loadReference() error {
+	if e.loaded {
+		return nil
+	}
+
+	sites, err := e.Source.GetSites(e.Token, e.Company)
+	if err != nil {
+		return err
+	}
+	categories, err := e.Source.GetCategories(e.Token, e.Company)
+	if err != nil {
+		return err
+	}
+	staff, err := e.Source.GetStaff(e.Token, e.Company)
+	if err != nil {
+		return err
+	}
+	methods, err := e.Source.ListPaymentMethods(e.Token, e.Company)
+	if err != nil {
+		return err
+	}
+
+	e.sites = map[int64]Site{}
+	for _, s := range sites {
+		e.sites[int64(s.ID)] = s
+	}
+	e.categories = map[int]Category{}
+	for _, c := range categories {
+		e.categories[c.ID] = c
+	}
+	e.staff = map[int]Staff{}
+	for _, s := range staff {
+		e.staff[s.ID] = s
+	}
+	e.methods = map[int64]PaymentMethod{}
+	for _, m := range methods {
+		e.methods[m.ID] = m
+	}
+
+	e.loaded = true
+	return nil
+}
+
+// entityJob is a customer or product to fetch, with what was found
+type entityJob struct {
+	customer bool
+	id       int64
+	found    interface{}
+	err      error
+}
+
+// loadEntities will fetch the customers and products of the orders not yet loaded with bounded concurrency.
+// Entities that are not found are stored as nil so they are not fetched again.
+func (e *Enricher) loadEntities(orders []Order) error {
+	jobs := []*entityJob{}
+	queued := map[entityJob]bool{}
+	queue := func(customer bool, id int64) {
+		key := entityJob{customer: customer, id: id}
+		if id == 0 || queued[key] {
+			return
+		}
+		queued[key] = true
+		jobs = append(jobs, &key)
+	}
+
+	for _, order := range orders {
+		if _, ok := e.customers[order.Customer.ID]; !ok {
+			queue(true, order.Customer.ID)
+		}
+		for _, item := range order.Items {
+			if _, ok := e.products[item.Product.ID]; !ok {
+				queue(false, item.Product.ID)
+			}
+		}
+	}
+
+	concurrency := e.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultEnrichConcurrency
+	}
+
+	work := make(chan *entityJob)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency && i < len(jobs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range work {
+				e.fetchEntity(job)
+			}
+		}()
+	}
+	for _, job := range jobs {
+		work <- job
+	}
+	close(work)
+	wg.Wait()
+
+	for _, job := range jobs {
+		if job.err != nil && !IsNotFound(job.err) {
+			return job.err
+		}
+		if job.customer {
+			c, _ := job.found.(*Customer)
+			e.customers[job.id] = c
+		} else {
+			p, _ := job.found.(*KountaProduct)
+			e.products[job.id] = p
+		}
+	}
+	return nil
+}
+
+func (e *Enricher) fetchEntity(job *entityJob) {
+	if job.customer {
+		c, err := e.Source.GetCustomer(e.Token, e.Company, job.id)
+		job.found, job.err = c, err
+		return
+	}
+	p, err := e.Source.GetProduct(e.Token, e.Company, job.id)
+	job.found, job.err = p, err
+}
+
+func (e *Enricher) enrich(order Order) EnrichedOrder {
+	enriched := EnrichedOrder{
+		Order:    order,
+		Customer: e.customers[order.Customer.ID],
+	}
+
+	if s, ok := e.sites[order.SiteID]; ok {
+		enriched.Site = &s
+	}
+	if s, ok := e.staff[order.Staff.ID]; ok {
+		enriched.Staff = &s
+	}
+
+	for _, item := range order.Items {
+		line := EnrichedOrderLine{Line: item, Product: e.products[item.Product.ID]}
+		if line.Product != nil && len(line.Product.Categories) > 0 {
+			if c, ok := e.categories[line.Product.Categories[0]]; ok {
+				line.Category = &c
+			}
+		}
+		enriched.Lines = append(enriched.Lines, line)
+	}
+
+	for _, payment := range order.Payments {
+		p := EnrichedOrderPayment{Payment: payment}
+		if m, ok := e.methods[payment.Method.ID]; ok {
+			p.Method = &m
+		}
+		enriched.Payments = append(enriched.Payments, p)
+	}
+
+	return enriched
+}
+
+// ProductCategory will return the category of a product already loaded by the enricher, for use in report options
+func (e *Enricher) ProductCategory(productID int64) (Category, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	p := e.products[productID]
+	if p == nil || len(p.Categories) == 0 {
+		return Category{}, false
+	}
+	c, ok := e.categories[p.Categories[0]]
+	return c, ok
+}
@@ -0,0 +1,90 @@
+package gokounta
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+// fakeSource serves known customers and products and counts the lookups
+type fakeSource struct {
+	mu        sync.Mutex
+	customers map[int64]*Customer
+	products  map[int64]*KountaProduct
+	calls     int
+	fail      error
+}
+
+func (f *fakeSource) GetSites(token string, company string) (Sites, error) { return Sites{}, nil }
+func (f *fakeSource) GetCategories(token string, company string) (Categories, error) {
+	return Categories{}, nil
+}
+func (f *fakeSource) GetStaff(token string, company string) (Staffs, error) { return Staffs{}, nil }
+func (f *fakeSource) ListPaymentMethods(token string, company string) (PaymentMethods, error) {
+	return PaymentMethods{}, nil
+}
+
+func (f *fakeSource) GetCustomer(token string, company string, id int64) (*Customer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.fail != nil {
+		return nil, f.fail
+	}
+	if c, ok := f.customers[id]; ok {
+		return c, nil
+	}
+	return nil, &NotFoundError{Resource: "Customer", Status: "404 Not Found"}
+}
+
+func (f *fakeSource) GetProduct(token string, company string, id int64) (*KountaProduct, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if p, ok := f.products[id]; ok {
+		return p, nil
+	}
+	return nil, &NotFoundError{Resource: "Product", Status: "404 Not Found"}
+}
+
+func TestEnrichOrdersMissingEntities(t *testing.T) {
+	source := &fakeSource{
+		customers: map[int64]*Customer{1: {}},
+		products:  map[int64]*KountaProduct{10: {}},
+	}
+	e := NewEnricher(source, "token", "1")
+
+	order := func(customer int64, products ...int64) Order {
+		o := Order{}
+		o.Customer.ID = customer
+		for _, id := range products {
+			line := OrderLine{}
+			line.Product.ID = id
+			o.Items = append(o.Items, line)
+		}
+		return o
+	}
+
+	enriched, err := e.EnrichOrders([]Order{order(1, 10, 11), order(2, 10), order(1, 11)})
+	if err != nil {
+		t.Fatalf("EnrichOrders error %v", err)
+	}
+	if source.calls != 4 {
+		t.Errorf("%d lookups, want one per customer and product", source.calls)
+	}
+	if enriched[0].Customer == nil || enriched[1].Customer != nil {
+		t.Errorf("customers %v %v, want found then nil", enriched[0].Customer, enriched[1].Customer)
+	}
+	if enriched[0].Lines[0].Product == nil || enriched[0].Lines[1].Product != nil {
+		t.Errorf("products %v %v, want found then nil", enriched[0].Lines[0].Product, enriched[0].Lines[1].Product)
+	}
+
+	if _, err := e.EnrichOrders([]Order{order(2, 11)}); err != nil || source.calls != 4 {
+		t.Errorf("second batch error %v with %d lookups, want missing entities remembered", err, source.calls)
+	}
+
+	source.fail = errors.New("Failed to get Kounta Customer 500 Internal Server Error")
+	if _, err := e.EnrichOrders([]Order{order(3)}); err == nil {
+		t.Errorf("EnrichOrders succeeded, want the server error")
+	}
+}
@@ -0,0 +1,6 @@
+module github.com/albimcleod/gokounta
+
+go 1.13
+
+// shift.go imports github.com/mholt/binding, which has no require or go.sum entry yet.
+// Run go mod tidy here and in kountaotel and kountaprom with access to the module proxy to pin it.
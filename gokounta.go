@@ -2,12 +2,16 @@ package gokounta
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"path"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -22,13 +26,32 @@ const (
 	categoriesProductsURL = "/v1/companies/%v/categories/%v/products"
 	ordersURL             = "v1/companies/%v/sites/%v/orders/pending.json"
 	staffURL              = "v1/companies/%v/staff"
+	staffSingleURL        = "v1/companies/%v/staff/%v.json"
+	rolesURL              = "v1/companies/%v/roles.json"
 	ordersCompleteURL     = "v1/companies/%v/sites/%v/orders/complete.json"
 	ordersSingleURL       = "v1/companies/%v/orders/%v.json"
+	ordersCreateURL       = "v1/companies/%v/orders.json"
+	inventoryURL          = "v1/companies/%v/sites/%v/inventory.json"
+	inventorySingleURL    = "v1/companies/%v/sites/%v/inventory/%v.json"
+	inventoryAdjustURL    = "v1/companies/%v/sites/%v/inventory/%v/adjustments.json"
+	priceListsURL         = "v1/companies/%v/price_lists.json"
+	priceListSingleURL    = "v1/companies/%v/price_lists/%v.json"
+	priceListProductURL   = "v1/companies/%v/price_lists/%v/products/%v.json"
+	optionSetsURL         = "v1/companies/%v/option_sets.json"
+	optionSetSingleURL    = "v1/companies/%v/option_sets/%v.json"
+	paymentMethodsURL     = "v1/companies/%v/payment_methods.json"
+	taxesURL              = "v1/companies/%v/taxes.json"
+	customerSingleURL     = "v1/companies/%v/customers/%v.json"
+	productSingleURL      = "v1/companies/%v/products/%v.json"
 	companyStatus         = "v1/companies/%v/status.json"
 )
 
 var (
-	defaultSendTimeout = time.Second * 30
+	defaultSendTimeout  = time.Second * 30
+	createOrderAttempts = 3
+	createOrderBackoff  = time.Second
+	createdOrderLimit   = 1000
+	createdOrderTTL     = time.Hour * 24
 )
 
 // Kounta The main struct of this package
@@ -38,6 +61,20 @@ type Kounta struct {
 	ClientSecret string
 	RedirectURL  string
 	Timeout      time.Duration
+	// BaseURL is the Kounta api the client talks to, set it to point the client at a fake such as kountatest
+	BaseURL string
+	// Limiter paces requests made through the client when set
+	Limiter RateLimiter
+
+	middleware    []Middleware
+	mu            sync.Mutex
+	createdOrders map[string]rememberedOrder
+}
+
+// rememberedOrder is an order the client created for an idempotency key
+type rememberedOrder struct {
+	id int64
+	at time.Time
 }
 
 // NewClient will create a Kounta client with default values
@@ -45,6 +82,7 @@ func NewClient(code string, clientID string, clientSecret string, redirectURL st
 	return &Kounta{
 		StoreCode:    code,
 		Timeout:      defaultSendTimeout,
+		BaseURL:      baseURL,
 		RedirectURL:  redirectURL,
 		ClientID:     clientID,
 		ClientSecret: clientSecret,
@@ -61,27 +99,9 @@ func (v *Kounta) AccessToken() (string, string, error) {
 	data.Add("redirect_uri", v.RedirectURL)
 	data.Add("grant_type", "authorization_code")
 
-	u, _ := url.ParseRequestURI(baseURL)
-	u.Path = tokenURL
-	urlStr := fmt.Sprintf("%v", u)
-
-	fmt.Printf("AccessToken %v %v\n", urlStr, data)
-
-	client := &http.Client{}
-	r, _ := http.NewRequest("POST", urlStr, bytes.NewBufferString(data.Encode()))
-
-	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-	r.Header.Add("Content-Length", strconv.Itoa(len(data.Encode())))
-
-	res, _ := client.Do(r)
-	fmt.Println(res.Status)
-
-	rawResBody, err := ioutil.ReadAll(res.Body)
-
-	fmt.Printf("AccessToken Body %v \n", string(rawResBody))
-
+	res, rawResBody, err := v.tokenRequest(RequestInfo{Endpoint: "AccessToken"}, data)
 	if err != nil {
-		return "", "", fmt.Errorf("%v", string(rawResBody))
+		return "", "", err
 	}
 
 	if res.StatusCode == 200 {
@@ -106,33 +126,11 @@ func (v *Kounta) RefreshToken(refreshtoken string) (string, string, error) {
 	data.Add("grant_type", "refresh_token")
 	data.Add("redirect_uri", v.RedirectURL)
 
-	u, err := url.ParseRequestURI(baseURL)
-	if err != nil {
-		return "", "", err
-	}
-
-	u.Path = tokenURL
-	urlStr := fmt.Sprintf("%v", u)
-
-	client := &http.Client{}
-	r, err := http.NewRequest("POST", urlStr, bytes.NewBufferString(data.Encode()))
-	if err != nil {
-		return "", "", err
-	}
-
-	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-	r.Header.Add("Content-Length", strconv.Itoa(len(data.Encode())))
-
-	res, _ := client.Do(r)
-	fmt.Println(res.Status)
-
-	rawResBody, err := ioutil.ReadAll(res.Body)
+	res, rawResBody, err := v.tokenRequest(RequestInfo{Endpoint: "RefreshToken"}, data)
 	if err != nil {
 		return "", "", err
 	}
 
-	fmt.Println("BODY", string(rawResBody))
-
 	if res.StatusCode >= 400 {
 		return "", "", fmt.Errorf("Failed to get refresh token: %s", res.Status)
 	}
@@ -151,34 +149,12 @@ func (v *Kounta) RefreshToken(refreshtoken string) (string, string, error) {
 
 // GetCompany will return the authenticated company
 func (v *Kounta) GetCompany(token string) (*Company, error) {
-	client := &http.Client{}
-	client.CheckRedirect = checkRedirectFunc
-
-	u, _ := url.ParseRequestURI(baseURL)
-	u.Path = companiesURL
-	urlStr := fmt.Sprintf("%v", u)
-
-	r, err := http.NewRequest("GET", urlStr, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	r.Header = http.Header(make(map[string][]string))
-	r.Header.Set("Accept", "application/json")
-	r.Header.Set("Authorization", "Bearer "+token)
-
-	res, err := client.Do(r)
-	if err != nil {
-		return nil, err
-	}
-
-	rawResBody, err := ioutil.ReadAll(res.Body)
+	info := RequestInfo{Endpoint: "GetCompany"}
+	res, rawResBody, err := v.call(info, "GET", token, v.endpoint(companiesURL), nil)
 	if err != nil {
 		return nil, err
 	}
 
-	//	fmt.Println("GetCompany Body", string(rawResBody))
-
 	if res.StatusCode == 200 {
 		var resp Company
 		err = json.Unmarshal(rawResBody, &resp)
@@ -188,40 +164,16 @@ func (v *Kounta) GetCompany(token string) (*Company, error) {
 		return &resp, nil
 	}
 	return nil, fmt.Errorf("Failed to get Kounta Company %s", res.Status)
-
 }
 
 // GetSites will return the sites of the authenticated company
-//not finished
 func (v *Kounta) GetSites(token string, company string) (Sites, error) {
-	client := &http.Client{}
-	client.CheckRedirect = checkRedirectFunc
-
-	u, _ := url.ParseRequestURI(baseURL)
-	u.Path = fmt.Sprintf(sitesURL, company)
-	urlStr := fmt.Sprintf("%v", u)
-
-	r, err := http.NewRequest("GET", urlStr, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	r.Header = http.Header(make(map[string][]string))
-	r.Header.Set("Accept", "application/json")
-	r.Header.Set("Authorization", "Bearer "+token)
-
-	res, err := client.Do(r)
-	if err != nil {
-		return nil, err
-	}
-
-	rawResBody, err := ioutil.ReadAll(res.Body)
+	info := RequestInfo{Endpoint: "GetSites", Company: company}
+	res, rawResBody, err := v.call(info, "GET", token, v.endpoint(fmt.Sprintf(sitesURL, company)), nil)
 	if err != nil {
 		return nil, err
 	}
 
-	fmt.Println("GetSites Body", string(rawResBody))
-
 	if res.StatusCode == 200 {
 		var resp Sites
 
@@ -232,39 +184,16 @@ func (v *Kounta) GetSites(token string, company string) (Sites, error) {
 		return resp, nil
 	}
 	return nil, fmt.Errorf("Failed to get Kounta Company %s", res.Status)
-
 }
 
 // GetStaff will return the staff of the authenticated company
 func (v *Kounta) GetStaff(token string, company string) (Staffs, error) {
-	client := &http.Client{}
-	client.CheckRedirect = checkRedirectFunc
-
-	u, _ := url.ParseRequestURI(baseURL)
-	u.Path = fmt.Sprintf(staffURL, company)
-	urlStr := fmt.Sprintf("%v", u)
-
-	r, err := http.NewRequest("GET", urlStr, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	r.Header = http.Header(make(map[string][]string))
-	r.Header.Set("Accept", "application/json")
-	r.Header.Set("Authorization", "Bearer "+token)
-
-	res, err := client.Do(r)
-	if err != nil {
-		return nil, err
-	}
-
-	rawResBody, err := ioutil.ReadAll(res.Body)
+	info := RequestInfo{Endpoint: "GetStaff", Company: company}
+	res, rawResBody, err := v.call(info, "GET", token, v.endpoint(fmt.Sprintf(staffURL, company)), nil)
 	if err != nil {
 		return nil, err
 	}
 
-	fmt.Println("GetStaff Body", string(rawResBody))
-
 	if res.StatusCode == 200 {
 		var resp Staffs
 
@@ -275,121 +204,128 @@ func (v *Kounta) GetStaff(token string, company string) (Staffs, error) {
 		return resp, nil
 	}
 	return nil, fmt.Errorf("Failed to get Kounta Staff %s", res.Status)
-
 }
 
-// GetWebHooks will return the webhooks of the authenticated company
-func (v *Kounta) GetWebHooks(token string, company string) (WebHooks, error) {
-	client := &http.Client{}
-	client.CheckRedirect = checkRedirectFunc
-
-	u, _ := url.ParseRequestURI(baseURL)
-	u.Path = fmt.Sprintf(webHookURL+".json", company)
-	urlStr := fmt.Sprintf("%v", u)
-
-	r, err := http.NewRequest("GET", urlStr, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	r.Header = http.Header(make(map[string][]string))
-	r.Header.Set("Accept", "application/json")
-	r.Header.Set("Authorization", "Bearer "+token)
-
-	res, err := client.Do(r)
-	if err != nil {
-		return nil, err
-	}
-
-	rawResBody, err := ioutil.ReadAll(res.Body)
+// GetStaffMember will return a single staff member of the authenticated company
+func (v *Kounta) GetStaffMember(token string, company string, staffID int) (*Staff, error) {
+	info := RequestInfo{Endpoint: "GetStaffMember", Company: company}
+	res, rawResBody, err := v.call(info, "GET", token, v.endpoint(fmt.Sprintf(staffSingleURL, company, staffID)), nil)
 	if err != nil {
 		return nil, err
 	}
 
 	if res.StatusCode == 200 {
-		var resp WebHooks
-
-		fmt.Println(string(rawResBody))
+		var resp Staff
 
 		err = json.Unmarshal(rawResBody, &resp)
-
 		if err != nil {
 			return nil, err
 		}
-		return resp, nil
+		return &resp, nil
 	}
-	return nil, fmt.Errorf("Failed to get Kounta Web Hooks %s", res.Status)
-
+	return nil, fmt.Errorf("Failed to get Kounta Staff Member %s", res.Status)
 }
 
-// CreateSaleWebHook will init the sales hook for the Kounta store
-func (v *Kounta) CreateSaleWebHook(token string, company string, webhook WebHook) error {
-
-	fmt.Println("CreateSaleWebHook", token, company, webhook)
-
-	b, err := json.Marshal(webhook)
+// CreateStaff will create an active staff member for the company and return the new staff id
+func (v *Kounta) CreateStaff(token string, company string, staff Staff) (int, error) {
+	info := RequestInfo{Endpoint: "CreateStaff", Company: company}
+	res, rawResBody, err := v.call(info, "POST", token, v.endpoint(fmt.Sprintf(staffURL+".json", company)), newStaffRequest(staff))
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	u, err := url.ParseRequestURI(baseURL)
-	if err != nil {
-		return err
+	if res.StatusCode >= 400 {
+		return 0, fmt.Errorf("Failed to create Kounta Staff %s", res.Status)
 	}
 
-	u.Path = fmt.Sprintf(webHookURL+".json", company)
-	urlStr := fmt.Sprintf("%v", u)
+	id, err := createdID(res, rawResBody)
+	if err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
 
-	client := &http.Client{}
-	client.CheckRedirect = checkRedirectFunc
+// UpdateStaff will change the fields of a staff member set in the update, leaving the rest unchanged
+func (v *Kounta) UpdateStaff(token string, company string, staffID int, update StaffUpdate) error {
+	if staffID == 0 {
+		return fmt.Errorf("Failed to update Kounta Staff: missing staff id")
+	}
 
-	r, err := http.NewRequest("POST", urlStr, bytes.NewBuffer(b))
+	info := RequestInfo{Endpoint: "UpdateStaff", Company: company}
+	res, _, err := v.call(info, "PUT", token, v.endpoint(fmt.Sprintf(staffSingleURL, company, staffID)), update)
 	if err != nil {
 		return err
 	}
 
-	r.Header.Add("Authorization", "Bearer "+token)
-	r.Header.Add("Content-Type", "application/json")
-	r.Header.Add("Content-Length", strconv.Itoa(len(b)))
+	if res.StatusCode >= 400 {
+		return fmt.Errorf("Failed to update Kounta Staff %s", res.Status)
+	}
+
+	return nil
+}
+
+// DeactivateStaff will mark a staff member of the company as inactive
+func (v *Kounta) DeactivateStaff(token string, company string, staffID int) error {
+	active := false
+	body := StaffUpdate{IsActive: &active}
 
-	res, err := client.Do(r)
+	info := RequestInfo{Endpoint: "DeactivateStaff", Company: company}
+	res, _, err := v.call(info, "PUT", token, v.endpoint(fmt.Sprintf(staffSingleURL, company, staffID)), body)
 	if err != nil {
 		return err
 	}
 
 	if res.StatusCode >= 400 {
-		return fmt.Errorf("Failed init sale webhooks %s", res.Status)
+		return fmt.Errorf("Failed to deactivate Kounta Staff %s", res.Status)
 	}
 
 	return nil
 }
 
-// DeleteSaleWebHook will init the sales hook for the Kounta store
-func (v *Kounta) DeleteSaleWebHook(token string, company string, id int) error {
-
-	fmt.Println("UpdateSaleWebHook", token, company, id)
-
-	u, err := url.ParseRequestURI(baseURL)
+// GetRoles will return the staff roles available to the authenticated company
+func (v *Kounta) GetRoles(token string, company string) (Roles, error) {
+	info := RequestInfo{Endpoint: "GetRoles", Company: company}
+	res, rawResBody, err := v.call(info, "GET", token, v.endpoint(fmt.Sprintf(rolesURL, company)), nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	u.Path = fmt.Sprintf(webHookURL+"/"+strconv.Itoa(id)+".json", company)
-	urlStr := fmt.Sprintf("%v", u)
+	if res.StatusCode == 200 {
+		var resp Roles
 
-	client := &http.Client{}
-	client.CheckRedirect = checkRedirectFunc
+		err = json.Unmarshal(rawResBody, &resp)
+		if err != nil {
+			return nil, err
+		}
+		return resp, nil
+	}
+	return nil, fmt.Errorf("Failed to get Kounta Roles %s", res.Status)
+}
 
-	r, err := http.NewRequest("DELETE", urlStr, nil) //, bytes.NewBuffer(b))
+// GetWebHooks will return the webhooks of the authenticated company
+func (v *Kounta) GetWebHooks(token string, company string) (WebHooks, error) {
+	info := RequestInfo{Endpoint: "GetWebHooks", Company: company}
+	res, rawResBody, err := v.call(info, "GET", token, v.endpoint(fmt.Sprintf(webHookURL+".json", company)), nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	r.Header.Add("Authorization", "Bearer "+token)
-	r.Header.Add("Content-Type", "application/json")
-	r.Header.Add("Content-Length", "0") // strconv.Itoa(len(b)))
+	if res.StatusCode == 200 {
+		var resp WebHooks
+
+		err = json.Unmarshal(rawResBody, &resp)
+		if err != nil {
+			return nil, err
+		}
+		return resp, nil
+	}
+	return nil, fmt.Errorf("Failed to get Kounta Web Hooks %s", res.Status)
+}
 
-	res, err := client.Do(r)
+// CreateSaleWebHook will init the sales hook for the Kounta store
+func (v *Kounta) CreateSaleWebHook(token string, company string, webhook WebHook) error {
+	info := RequestInfo{Endpoint: "CreateSaleWebHook", Company: company}
+	res, _, err := v.call(info, "POST", token, v.endpoint(fmt.Sprintf(webHookURL+".json", company)), webhook)
 	if err != nil {
 		return err
 	}
@@ -401,91 +337,65 @@ func (v *Kounta) DeleteSaleWebHook(token string, company string, id int) error {
 	return nil
 }
 
-// GetCategories will return the categories of the authenticated company
-func (v *Kounta) GetCategories(token string, company string) (Categories, error) {
-	client := &http.Client{}
-	client.CheckRedirect = checkRedirectFunc
-
-	u, _ := url.ParseRequestURI(baseURL)
-	u.Path = fmt.Sprintf(categoriesURL, company)
-	urlStr := fmt.Sprintf("%v", u)
-
-	r, err := http.NewRequest("GET", urlStr, nil)
+// DeleteSaleWebHook will remove a webhook from the Kounta store
+func (v *Kounta) DeleteSaleWebHook(token string, company string, id int) error {
+	info := RequestInfo{Endpoint: "DeleteSaleWebHook", Company: company}
+	res, _, err := v.call(info, "DELETE", token, v.endpoint(fmt.Sprintf(webHookURL+"/"+strconv.Itoa(id)+".json", company)), nil)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	r.Header = http.Header(make(map[string][]string))
-	r.Header.Set("Accept", "application/json")
-	r.Header.Set("Authorization", "Bearer "+token)
-
-	res, err := client.Do(r)
-	if err != nil {
-		return nil, err
+	if res.StatusCode >= 400 {
+		return fmt.Errorf("Failed init sale webhooks %s", res.Status)
 	}
 
-	rawResBody, err := ioutil.ReadAll(res.Body)
+	return nil
+}
+
+// GetCategories will return the categories of the authenticated company
+func (v *Kounta) GetCategories(token string, company string) (Categories, error) {
+	info := RequestInfo{Endpoint: "GetCategories", Company: company}
+	res, rawResBody, err := v.call(info, "GET", token, v.endpoint(fmt.Sprintf(categoriesURL, company)), nil)
 	if err != nil {
 		return nil, err
 	}
 
 	if res.StatusCode == 200 {
-		var resp []Category
+		var resp Categories
 
 		err = json.Unmarshal(rawResBody, &resp)
-
 		if err != nil {
 			return nil, err
 		}
 		return resp, nil
 	}
 	return nil, fmt.Errorf("Failed to get Kounta Categories %s", res.Status)
-
 }
 
 // GetProducts will return the products of the authenticated company
 func (v *Kounta) GetProducts(token string, company string, categoryID string) (KountaProducts, error) {
+	urlStr := v.endpoint(fmt.Sprintf(categoriesProductsURL, company, categoryID))
+	info := RequestInfo{Endpoint: "GetProducts", Company: company}
 
-	u, _ := url.ParseRequestURI(baseURL)
-	u.Path = fmt.Sprintf(categoriesProductsURL, company, categoryID)
-
-	urlStr := fmt.Sprintf("%v", u)
-
-	results := new(KountaProducts)
+	results := KountaProducts{}
 
 	for urlStr != "" {
+		info.Page++
 
-		resp := new(KountaProducts)
-
-		*resp, _, urlStr = v.callProduct(urlStr, token)
-
-		*results = append(*results, *resp...)
+		resp, err, next := v.callProduct(info, urlStr, token)
+		if err != nil {
+			return nil, err
+		}
 
-		fmt.Println("X-Next-Page", urlStr, len(*results))
+		results = append(results, resp...)
+		urlStr = next
 	}
 
-	return *results, nil
+	return results, nil
 }
 
-func (v *Kounta) callProduct(urlStr string, token string) (KountaProducts, error, string) {
-	client := &http.Client{}
-	client.CheckRedirect = checkRedirectFunc
-
-	r, err := http.NewRequest("GET", urlStr, nil)
-	if err != nil {
-		return nil, err, ""
-	}
-
-	r.Header = http.Header(make(map[string][]string))
-	r.Header.Set("Accept", "application/json")
-	r.Header.Set("Authorization", "Bearer "+token)
-
-	res, err := client.Do(r)
-	if err != nil {
-		return nil, err, ""
-	}
-
-	rawResBody, err := ioutil.ReadAll(res.Body)
+func (v *Kounta) callProduct(info RequestInfo, urlStr string, token string) (KountaProducts, error, string) {
+	res, rawResBody, err := v.call(info, "GET", token, urlStr, nil)
 	if err != nil {
 		return nil, err, ""
 	}
@@ -503,83 +413,87 @@ func (v *Kounta) callProduct(urlStr string, token string) (KountaProducts, error
 	return nil, fmt.Errorf("Failed to get Kounta Products %s", res.Status), ""
 }
 
-// GetOrders will return the orders of the authenticated company
+// GetOrders will return all pending orders of a site, following every page
 func (v *Kounta) GetOrders(token string, company string, siteID string) ([]Order, error) {
-	client := &http.Client{}
-	client.CheckRedirect = checkRedirectFunc
+	return v.QueryPendingOrders(token, company, siteID, OrderQuery{})
+}
 
-	u, _ := url.ParseRequestURI(baseURL)
-	u.Path = fmt.Sprintf(ordersURL, company, siteID)
-	urlStr := fmt.Sprintf("%v", u)
+// QueryPendingOrders will return the pending orders of a site matching the query
+func (v *Kounta) QueryPendingOrders(token string, company string, siteID string, query OrderQuery) ([]Order, error) {
+	return v.QueryPendingOrdersContext(context.Background(), token, company, siteID, query)
+}
 
-	r, err := http.NewRequest("GET", urlStr, nil)
-	if err != nil {
-		return nil, err
+// QueryPendingOrdersContext will return the pending orders of a site matching the query, stopping when ctx is done
+func (v *Kounta) QueryPendingOrdersContext(ctx context.Context, token string, company string, siteID string, query OrderQuery) ([]Order, error) {
+	info := RequestInfo{Endpoint: "QueryPendingOrders", Company: company, Site: siteID}
+	return v.queryOrders(ctx, info, token, v.endpoint(fmt.Sprintf(ordersURL, company, siteID)), query)
+}
+
+// QueryCompleteOrders will return the completed orders of a site matching the query
+func (v *Kounta) QueryCompleteOrders(token string, company string, siteID string, query OrderQuery) ([]Order, error) {
+	return v.QueryCompleteOrdersContext(context.Background(), token, company, siteID, query)
+}
+
+// QueryCompleteOrdersContext will return the completed orders of a site matching the query, stopping when ctx is done
+func (v *Kounta) QueryCompleteOrdersContext(ctx context.Context, token string, company string, siteID string, query OrderQuery) ([]Order, error) {
+	info := RequestInfo{Endpoint: "QueryCompleteOrders", Company: company, Site: siteID}
+	return v.queryOrders(ctx, info, token, v.endpoint(fmt.Sprintf(ordersCompleteURL, company, siteID)), query)
+}
+
+func (v *Kounta) queryOrders(ctx context.Context, info RequestInfo, token string, urlStr string, query OrderQuery) ([]Order, error) {
+	if values := query.values(); len(values) > 0 {
+		urlStr += "?" + values.Encode()
 	}
 
-	r.Header = http.Header(make(map[string][]string))
-	r.Header.Set("Accept", "application/json")
-	r.Header.Set("Authorization", "Bearer "+token)
+	results := []Order{}
+
+	for page := 0; urlStr != "" && (query.MaxPages <= 0 || page < query.MaxPages); page++ {
+		info.Page = page + 1
+
+		resp, next, err := v.callOrders(ctx, info, urlStr, token)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, resp...)
+		urlStr = next
+	}
+
+	return results, nil
+}
 
-	res, err := client.Do(r)
+func (v *Kounta) callOrders(ctx context.Context, info RequestInfo, urlStr string, token string) ([]Order, string, error) {
+	r, err := v.newRequest(ctx, info, "GET", token, urlStr, nil)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	rawResBody, err := ioutil.ReadAll(res.Body)
+	res, rawResBody, err := v.send(r)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	if res.StatusCode == 200 {
 		var resp []Order
 
-		//fmt.Println(string(rawResBody))
-
 		err = json.Unmarshal(rawResBody, &resp)
-
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
-		return resp, nil
+		return resp, res.Header.Get("X-Next-Page"), nil
 	}
-	return nil, fmt.Errorf("Failed to get Kounta Categories %s", res.Status)
-
+	return nil, "", fmt.Errorf("Failed to get Kounta Orders %s", res.Status)
 }
 
-// GetOrdersComplete will return the orders of the authenticated company
+// GetOrdersComplete will return a page of completed orders of a site, starting from the start cursor
 func (v *Kounta) GetOrdersComplete(token string, company string, siteID string, start string) ([]Order, error) {
-	client := &http.Client{}
-	client.CheckRedirect = checkRedirectFunc
-
-	u, _ := url.ParseRequestURI(baseURL)
-	u.Path = fmt.Sprintf(ordersCompleteURL, company, siteID)
-	urlStr := fmt.Sprintf("%v", u)
-
-	fmt.Println("u.Path ", u.Path)
-
-	//urlStr += "?created_gte=2018-08-28"
+	urlStr := v.endpoint(fmt.Sprintf(ordersCompleteURL, company, siteID))
 	if start != "" {
 		urlStr += "?start=" + start
 	}
 
-	//fmt.Println(urlStr)
-
-	r, err := http.NewRequest("GET", urlStr, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	r.Header = http.Header(make(map[string][]string))
-	r.Header.Set("Accept", "application/json")
-	r.Header.Set("Authorization", "Bearer "+token)
-
-	res, err := client.Do(r)
-	if err != nil {
-		return nil, err
-	}
-
-	rawResBody, err := ioutil.ReadAll(res.Body)
+	info := RequestInfo{Endpoint: "GetOrdersComplete", Company: company, Site: siteID, Page: 1}
+	res, rawResBody, err := v.call(info, "GET", token, urlStr, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -587,10 +501,6 @@ func (v *Kounta) GetOrdersComplete(token string, company string, siteID string,
 	if res.StatusCode == 200 {
 		var resp []Order
 
-		fmt.Println(res.Header["X-Next-Page"])
-
-		//	fmt.Println(string(rawResBody))
-
 		err = json.Unmarshal(rawResBody, &resp)
 
 		if err != nil {
@@ -598,99 +508,812 @@ func (v *Kounta) GetOrdersComplete(token string, company string, siteID string,
 		}
 		return resp, nil
 	}
-	fmt.Println(string(rawResBody))
 	return nil, fmt.Errorf("Failed to get Kounta Categories %s", res.Status)
-
 }
 
-// GetOrders will return the orders of the authenticated company
+// GetOrdersSingle will return a single order of the authenticated company
 func (v *Kounta) GetOrdersSingle(token string, company string, orderID string) (*Order, error) {
-	client := &http.Client{}
-	client.CheckRedirect = checkRedirectFunc
+	info := RequestInfo{Endpoint: "GetOrdersSingle", Company: company}
+	res, rawResBody, err := v.call(info, "GET", token, v.endpoint(fmt.Sprintf(ordersSingleURL, company, orderID)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == 200 {
+		resp := Order{}
+
+		err = json.Unmarshal(rawResBody, &resp)
+
+		if err != nil {
+			return nil, err
+		}
+		return &resp, nil
+	}
+	return nil, fmt.Errorf("Failed to get Kounta Sale %s", res.Status)
+}
+
+// CreateOrder will push a new order into the company and return the created order id.
+// It is CreateOrderContext without a context.
+func (v *Kounta) CreateOrder(token string, company string, order Order, idempotencyKey string) (int64, error) {
+	return v.CreateOrderContext(context.Background(), token, company, order, idempotencyKey)
+}
+
+// CreateOrderContext will push a new order into the company and return the created order id, stopping when ctx is done.
+// The idempotency key is added to the order notes as a "ref:<key>" line. Notes are printed on kitchen tickets and
+// receipts, so the key should be something staff and customers may see, such as the online order number.
+// The client remembers the orders it created by key for a day, so repeating a call returns the first order.
+// When a create fails in a way that may still have created the order, such as a timeout or a 5xx, the site's
+// orders are searched for the reference before retrying. An empty key disables both and such failures are
+// returned without retrying.
+func (v *Kounta) CreateOrderContext(ctx context.Context, token string, company string, order Order, idempotencyKey string) (int64, error) {
+	if err := order.ValidateNew(); err != nil {
+		return 0, err
+	}
+
+	if id, ok := v.createdOrder(idempotencyKey); ok {
+		return id, nil
+	}
+
+	if idempotencyKey != "" {
+		order.Notes = withOrderReference(order.Notes, idempotencyKey)
+	}
+
+	urlStr := v.endpoint(fmt.Sprintf(ordersCreateURL, company))
+	body := newOrderRequest(order)
+	started := time.Now()
+
+	var lastErr error
+	ambiguous := false
+	for attempt := 0; attempt < createOrderAttempts; attempt++ {
+		if attempt > 0 {
+			if err := sleepContext(ctx, createOrderBackoff*time.Duration(attempt)); err != nil {
+				return 0, err
+			}
+		}
+
+		if ambiguous {
+			// the last attempt may have created the order, only retry once it is known that it did not
+			if idempotencyKey == "" {
+				return 0, lastErr
+			}
+
+			id, found, err := v.findOrderByReference(ctx, token, company, order, idempotencyKey, started)
+			if err != nil {
+				return 0, lastErr
+			}
+			if found {
+				v.setCreatedOrder(idempotencyKey, id)
+				return id, nil
+			}
+		}
+
+		info := RequestInfo{Endpoint: "CreateOrder", Company: company, Attempt: attempt + 1}
+		r, err := v.newRequest(ctx, info, "POST", token, urlStr, body)
+		if err != nil {
+			return 0, err
+		}
+
+		res, rawResBody, err := v.send(r)
+		if err != nil {
+			if ctx.Err() != nil {
+				return 0, err
+			}
+			lastErr, ambiguous = err, true
+			continue
+		}
+
+		if res.StatusCode == http.StatusTooManyRequests {
+			// the request was refused so retrying cannot create a duplicate
+			lastErr, ambiguous = fmt.Errorf("Failed to create Kounta Order %s", res.Status), false
+			continue
+		}
+
+		if res.StatusCode >= 500 {
+			lastErr, ambiguous = fmt.Errorf("Failed to create Kounta Order %s", res.Status), true
+			continue
+		}
+
+		if res.StatusCode >= 400 {
+			return 0, fmt.Errorf("Failed to create Kounta Order %s: %s", res.Status, string(rawResBody))
+		}
+
+		id, err := createdID(res, rawResBody)
+		if err != nil {
+			return 0, err
+		}
+
+		v.setCreatedOrder(idempotencyKey, id)
+		return id, nil
+	}
+
+	return 0, lastErr
+}
+
+// UpdateOrder will update the notes, customer and lines of an open order
+func (v *Kounta) UpdateOrder(token string, company string, order Order) error {
+	if order.ID == 0 {
+		return fmt.Errorf("Failed to update Kounta Order: missing order id")
+	}
+	if !order.Status.IsOpen() {
+		return fmt.Errorf("Failed to update Kounta Order: order is %s", order.Status)
+	}
+	if err := order.ValidateNew(); err != nil {
+		return err
+	}
+
+	body := newOrderUpdateRequest(order)
+
+	info := RequestInfo{Endpoint: "UpdateOrder", Company: company}
+	res, rawResBody, err := v.call(info, "PUT", token, v.endpoint(fmt.Sprintf(ordersSingleURL, company, order.ID)), body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode >= 400 {
+		return fmt.Errorf("Failed to update Kounta Order %s: %s", res.Status, string(rawResBody))
+	}
+
+	return nil
+}
+
+// TransitionOrder will move an order to a new status, refusing transitions Kounta does not allow
+func (v *Kounta) TransitionOrder(token string, company string, order *Order, status OrderStatus) error {
+	if !order.Status.CanTransition(status) {
+		return fmt.Errorf("Invalid Kounta Order transition from %s to %s", order.Status, status)
+	}
+
+	body := map[string]OrderStatus{"status": status}
+
+	info := RequestInfo{Endpoint: "TransitionOrder", Company: company}
+	res, rawResBody, err := v.call(info, "PUT", token, v.endpoint(fmt.Sprintf(ordersSingleURL, company, order.ID)), body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode >= 400 {
+		return fmt.Errorf("Failed to update Kounta Order status %s: %s", res.Status, string(rawResBody))
+	}
+
+	order.Status = status
+	return nil
+}
+
+// HoldOrder will put a pending order on hold
+func (v *Kounta) HoldOrder(token string, company string, order *Order) error {
+	return v.TransitionOrder(token, company, order, OrderStatusOnHold)
+}
+
+// ResumeOrder will move an order on hold back to pending
+func (v *Kounta) ResumeOrder(token string, company string, order *Order) error {
+	return v.TransitionOrder(token, company, order, OrderStatusPending)
+}
+
+// CompleteOrder will mark an open order as complete
+func (v *Kounta) CompleteOrder(token string, company string, order *Order) error {
+	return v.TransitionOrder(token, company, order, OrderStatusComplete)
+}
+
+// CancelOrder will cancel an open order
+func (v *Kounta) CancelOrder(token string, company string, order *Order) error {
+	return v.TransitionOrder(token, company, order, OrderStatusCancelled)
+}
+
+// findOrderByReference will search the site's pending and completed orders since the create started for the key reference
+func (v *Kounta) findOrderByReference(ctx context.Context, token string, company string, order Order, key string, started time.Time) (int64, bool, error) {
+	from := started
+	if !order.SaleDate.IsZero() && order.SaleDate.Before(from) {
+		from = order.SaleDate.Time
+	}
+	query := OrderQuery{CreatedFrom: NewTimestamp(from.Add(-time.Minute))}
+	siteID := strconv.FormatInt(order.SiteID, 10)
+
+	for _, list := range []func(context.Context, string, string, string, OrderQuery) ([]Order, error){v.QueryPendingOrdersContext, v.QueryCompleteOrdersContext} {
+		orders, err := list(ctx, token, company, siteID, query)
+		if err != nil {
+			return 0, false, err
+		}
+		for _, o := range orders {
+			if hasOrderReference(o.Notes, key) {
+				return o.ID, true, nil
+			}
+		}
+	}
+
+	return 0, false, nil
+}
+
+// createdOrder will return the order created for the key when it was created within createdOrderTTL
+func (v *Kounta) createdOrder(key string) (int64, bool) {
+	if key == "" {
+		return 0, false
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	o, ok := v.createdOrders[key]
+	if !ok || time.Since(o.at) > createdOrderTTL {
+		return 0, false
+	}
+	return o.id, true
+}
 
-	u, _ := url.ParseRequestURI(baseURL)
-	u.Path = fmt.Sprintf(ordersSingleURL, company, orderID)
-	urlStr := fmt.Sprintf("%v", u)
+// setCreatedOrder will remember the order created for the key, dropping expired and then the oldest keys
+// so no more than createdOrderLimit are held
+func (v *Kounta) setCreatedOrder(key string, id int64) {
+	if key == "" {
+		return
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.createdOrders == nil {
+		v.createdOrders = make(map[string]rememberedOrder)
+	}
+
+	if len(v.createdOrders) >= createdOrderLimit {
+		oldest := ""
+		for k, o := range v.createdOrders {
+			if time.Since(o.at) > createdOrderTTL {
+				delete(v.createdOrders, k)
+			} else if oldest == "" || o.at.Before(v.createdOrders[oldest].at) {
+				oldest = k
+			}
+		}
+		if len(v.createdOrders) >= createdOrderLimit {
+			delete(v.createdOrders, oldest)
+		}
+	}
+
+	v.createdOrders[key] = rememberedOrder{id: id, at: time.Now()}
+}
+
+// GetStockLevels will return the stock on hand of every product at a site
+func (v *Kounta) GetStockLevels(token string, company string, siteID string) (StockLevels, error) {
+	urlStr := v.endpoint(fmt.Sprintf(inventoryURL, company, siteID))
+	info := RequestInfo{Endpoint: "GetStockLevels", Company: company, Site: siteID}
+
+	results := StockLevels{}
+
+	for urlStr != "" {
+		info.Page++
+
+		resp, next, err := v.callStockLevels(info, urlStr, token)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, resp...)
+		urlStr = next
+	}
+
+	return results, nil
+}
+
+func (v *Kounta) callStockLevels(info RequestInfo, urlStr string, token string) (StockLevels, string, error) {
+	res, rawResBody, err := v.call(info, "GET", token, urlStr, nil)
+	if err != nil {
+		return nil, "", err
+	}
 
-	fmt.Println(urlStr)
+	if res.StatusCode == 200 {
+		var resp StockLevels
+
+		err = json.Unmarshal(rawResBody, &resp)
+		if err != nil {
+			return nil, "", err
+		}
+		return resp, res.Header.Get("X-Next-Page"), nil
+	}
+	return nil, "", fmt.Errorf("Failed to get Kounta Stock Levels %s", res.Status)
+}
 
-	r, err := http.NewRequest("GET", urlStr, nil)
+// GetStockLevel will return the stock on hand of a product at a site
+func (v *Kounta) GetStockLevel(token string, company string, siteID string, productID int64) (*StockLevel, error) {
+	info := RequestInfo{Endpoint: "GetStockLevel", Company: company, Site: siteID}
+	res, rawResBody, err := v.call(info, "GET", token, v.endpoint(fmt.Sprintf(inventorySingleURL, company, siteID, productID)), nil)
 	if err != nil {
 		return nil, err
 	}
 
-	r.Header = http.Header(make(map[string][]string))
-	r.Header.Set("Accept", "application/json")
-	r.Header.Set("Authorization", "Bearer "+token)
+	if res.StatusCode == 200 {
+		var resp StockLevel
 
-	res, err := client.Do(r)
+		err = json.Unmarshal(rawResBody, &resp)
+		if err != nil {
+			return nil, err
+		}
+		return &resp, nil
+	}
+	return nil, fmt.Errorf("Failed to get Kounta Stock Level %s", res.Status)
+}
+
+// AdjustStock will change the stock on hand of a product at a site by the adjustment quantity
+func (v *Kounta) AdjustStock(token string, company string, siteID string, adjustment StockAdjustment) error {
+	if err := adjustment.Validate(); err != nil {
+		return err
+	}
+
+	info := RequestInfo{Endpoint: "AdjustStock", Company: company, Site: siteID}
+	res, rawResBody, err := v.call(info, "POST", token, v.endpoint(fmt.Sprintf(inventoryAdjustURL, company, siteID, adjustment.ProductID)), adjustment)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode >= 400 {
+		return fmt.Errorf("Failed to adjust Kounta Stock %s: %s", res.Status, string(rawResBody))
+	}
+
+	return nil
+}
+
+// TransferStock will move stock of a product from one site to another.
+// If the stock cannot be added to the destination the removal from the source is reversed.
+func (v *Kounta) TransferStock(token string, company string, fromSiteID string, toSiteID string, productID int64, quantity float64, notes string) error {
+	if quantity <= 0 {
+		return fmt.Errorf("Failed to transfer Kounta Stock: quantity must be positive")
+	}
+
+	out := StockAdjustment{ProductID: productID, Quantity: -quantity, Reason: StockReasonTransferOut, Notes: notes}
+	if err := v.AdjustStock(token, company, fromSiteID, out); err != nil {
+		return err
+	}
+
+	in := StockAdjustment{ProductID: productID, Quantity: quantity, Reason: StockReasonTransferIn, Notes: notes}
+	if err := v.AdjustStock(token, company, toSiteID, in); err != nil {
+		reverse := StockAdjustment{ProductID: productID, Quantity: quantity, Reason: StockReasonTransferReversed, Notes: notes}
+		if rerr := v.AdjustStock(token, company, fromSiteID, reverse); rerr != nil {
+			return fmt.Errorf("Failed to transfer Kounta Stock: %v, and failed to reverse it: %v", err, rerr)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// SetLowStockThreshold will set the stock level at which a product counts as low at a site
+func (v *Kounta) SetLowStockThreshold(token string, company string, siteID string, productID int64, threshold float64) error {
+	body := map[string]float64{"low_stock_threshold": threshold}
+
+	info := RequestInfo{Endpoint: "SetLowStockThreshold", Company: company, Site: siteID}
+	res, _, err := v.call(info, "PUT", token, v.endpoint(fmt.Sprintf(inventorySingleURL, company, siteID, productID)), body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode >= 400 {
+		return fmt.Errorf("Failed to update Kounta Low Stock Threshold %s", res.Status)
+	}
+
+	return nil
+}
+
+// ListPriceLists will return the price lists of the company
+func (v *Kounta) ListPriceLists(token string, company string) (PriceLists, error) {
+	info := RequestInfo{Endpoint: "ListPriceLists", Company: company}
+	res, rawResBody, err := v.call(info, "GET", token, v.endpoint(fmt.Sprintf(priceListsURL, company)), nil)
 	if err != nil {
 		return nil, err
 	}
 
-	rawResBody, err := ioutil.ReadAll(res.Body)
+	if res.StatusCode == 200 {
+		var resp PriceLists
+
+		err = json.Unmarshal(rawResBody, &resp)
+		if err != nil {
+			return nil, err
+		}
+		return resp, nil
+	}
+	return nil, fmt.Errorf("Failed to get Kounta Price Lists %s", res.Status)
+}
+
+// GetPriceList will return a price list of the company with its product prices
+func (v *Kounta) GetPriceList(token string, company string, priceListID int) (*PriceList, error) {
+	info := RequestInfo{Endpoint: "GetPriceList", Company: company}
+	res, rawResBody, err := v.call(info, "GET", token, v.endpoint(fmt.Sprintf(priceListSingleURL, company, priceListID)), nil)
 	if err != nil {
 		return nil, err
 	}
 
 	if res.StatusCode == 200 {
-		resp := Order{}
+		var resp PriceList
 
-		//	fmt.Println(string(rawResBody))
+		err = json.Unmarshal(rawResBody, &resp)
+		if err != nil {
+			return nil, err
+		}
+		return &resp, nil
+	}
+	return nil, fmt.Errorf("Failed to get Kounta Price List %s", res.Status)
+}
+
+// CreatePriceList will create a price list for the company and return the new price list id
+func (v *Kounta) CreatePriceList(token string, company string, priceList PriceList) (int, error) {
+	priceList.ID = 0
+
+	info := RequestInfo{Endpoint: "CreatePriceList", Company: company}
+	res, rawResBody, err := v.call(info, "POST", token, v.endpoint(fmt.Sprintf(priceListsURL, company)), priceList)
+	if err != nil {
+		return 0, err
+	}
+
+	if res.StatusCode >= 400 {
+		return 0, fmt.Errorf("Failed to create Kounta Price List %s", res.Status)
+	}
+
+	id, err := createdID(res, rawResBody)
+	if err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+// UpdatePriceList will update the name, parent and product prices of a price list
+func (v *Kounta) UpdatePriceList(token string, company string, priceList PriceList) error {
+	if priceList.ID == 0 {
+		return fmt.Errorf("Failed to update Kounta Price List: missing price list id")
+	}
+
+	info := RequestInfo{Endpoint: "UpdatePriceList", Company: company}
+	res, _, err := v.call(info, "PUT", token, v.endpoint(fmt.Sprintf(priceListSingleURL, company, priceList.ID)), priceList)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode >= 400 {
+		return fmt.Errorf("Failed to update Kounta Price List %s", res.Status)
+	}
+
+	return nil
+}
+
+// GetPriceListEntry will return the price of a product on a price list
+func (v *Kounta) GetPriceListEntry(token string, company string, priceListID int, productID int) (*PriceListEntry, error) {
+	info := RequestInfo{Endpoint: "GetPriceListEntry", Company: company}
+	res, rawResBody, err := v.call(info, "GET", token, v.endpoint(fmt.Sprintf(priceListProductURL, company, priceListID, productID)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == 200 {
+		var resp PriceListEntry
 
 		err = json.Unmarshal(rawResBody, &resp)
+		if err != nil {
+			return nil, err
+		}
+		return &resp, nil
+	}
+	return nil, fmt.Errorf("Failed to get Kounta Price List Entry %s", res.Status)
+}
 
+// SetPriceListEntry will set the price of a product on a price list
+func (v *Kounta) SetPriceListEntry(token string, company string, priceListID int, entry PriceListEntry) error {
+	info := RequestInfo{Endpoint: "SetPriceListEntry", Company: company}
+	res, _, err := v.call(info, "PUT", token, v.endpoint(fmt.Sprintf(priceListProductURL, company, priceListID, entry.ProductID)), entry)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode >= 400 {
+		return fmt.Errorf("Failed to update Kounta Price List Entry %s", res.Status)
+	}
+
+	return nil
+}
+
+// SetPriceListEntries will set the price of many products on a price list, carrying on past failures
+func (v *Kounta) SetPriceListEntries(token string, company string, priceListID int, entries []PriceListEntry) error {
+	failed := []string{}
+	for _, entry := range entries {
+		if err := v.SetPriceListEntry(token, company, priceListID, entry); err != nil {
+			failed = append(failed, fmt.Sprintf("product %v: %v", entry.ProductID, err))
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("Failed to update %v Kounta Price List Entries: %s", len(failed), strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// GetOptionSets will return the modifier option sets of the company
+func (v *Kounta) GetOptionSets(token string, company string) (OptionSets, error) {
+	info := RequestInfo{Endpoint: "GetOptionSets", Company: company}
+	res, rawResBody, err := v.call(info, "GET", token, v.endpoint(fmt.Sprintf(optionSetsURL, company)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == 200 {
+		var resp OptionSets
+
+		err = json.Unmarshal(rawResBody, &resp)
+		if err != nil {
+			return nil, err
+		}
+		return resp, nil
+	}
+	return nil, fmt.Errorf("Failed to get Kounta Option Sets %s", res.Status)
+}
+
+// GetOptionSet will return a modifier option set of the company
+func (v *Kounta) GetOptionSet(token string, company string, optionSetID int) (*OptionSet, error) {
+	info := RequestInfo{Endpoint: "GetOptionSet", Company: company}
+	res, rawResBody, err := v.call(info, "GET", token, v.endpoint(fmt.Sprintf(optionSetSingleURL, company, optionSetID)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == 200 {
+		var resp OptionSet
+
+		err = json.Unmarshal(rawResBody, &resp)
 		if err != nil {
 			return nil, err
 		}
 		return &resp, nil
 	}
-	fmt.Println(string(rawResBody))
-	return nil, fmt.Errorf("Failed to get Kounta Sale %s", res.Status)
+	return nil, fmt.Errorf("Failed to get Kounta Option Set %s", res.Status)
+}
 
+// NewModifierLookup will create a cached modifier lookup that loads the company's option sets.
+// token is called on every reload, so it can return the latest access token after a refresh.
+func (v *Kounta) NewModifierLookup(token func() string, company string, ttl time.Duration) *ModifierLookup {
+	return NewModifierLookup(func() (OptionSets, error) {
+		return v.GetOptionSets(token(), company)
+	}, ttl)
 }
 
-// GetStatus will return the orders of the authenticated company
-func (v *Kounta) GetStatus(token string, company string) error {
-	client := &http.Client{}
-	client.CheckRedirect = checkRedirectFunc
+// ListPaymentMethods will return the payment methods of the company
+func (v *Kounta) ListPaymentMethods(token string, company string) (PaymentMethods, error) {
+	info := RequestInfo{Endpoint: "ListPaymentMethods", Company: company}
+	res, rawResBody, err := v.call(info, "GET", token, v.endpoint(fmt.Sprintf(paymentMethodsURL, company)), nil)
+	if err != nil {
+		return nil, err
+	}
 
-	u, _ := url.ParseRequestURI(baseURL)
-	u.Path = fmt.Sprintf(companyStatus, company)
-	urlStr := fmt.Sprintf("%v", u)
+	if res.StatusCode == 200 {
+		var resp PaymentMethods
 
-	fmt.Println(urlStr)
+		err = json.Unmarshal(rawResBody, &resp)
+		if err != nil {
+			return nil, err
+		}
+		return resp, nil
+	}
+	return nil, fmt.Errorf("Failed to get Kounta Payment Methods %s", res.Status)
+}
 
-	r, err := http.NewRequest("GET", urlStr, nil)
+// ListTaxes will return the taxes of the company
+func (v *Kounta) ListTaxes(token string, company string) (Taxes, error) {
+	info := RequestInfo{Endpoint: "ListTaxes", Company: company}
+	res, rawResBody, err := v.call(info, "GET", token, v.endpoint(fmt.Sprintf(taxesURL, company)), nil)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if res.StatusCode == 200 {
+		var resp Taxes
+
+		err = json.Unmarshal(rawResBody, &resp)
+		if err != nil {
+			return nil, err
+		}
+		return resp, nil
+	}
+	return nil, fmt.Errorf("Failed to get Kounta Taxes %s", res.Status)
+}
+
+// GetCustomer will return a customer of the company, a NotFoundError when there is no such customer
+func (v *Kounta) GetCustomer(token string, company string, customerID int64) (*Customer, error) {
+	info := RequestInfo{Endpoint: "GetCustomer", Company: company}
+	res, rawResBody, err := v.call(info, "GET", token, v.endpoint(fmt.Sprintf(customerSingleURL, company, customerID)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == 200 {
+		var resp Customer
+
+		err = json.Unmarshal(rawResBody, &resp)
+		if err != nil {
+			return nil, err
+		}
+		return &resp, nil
+	}
+	if res.StatusCode == http.StatusNotFound {
+		return nil, &NotFoundError{Resource: "Customer", Status: res.Status}
+	}
+	return nil, fmt.Errorf("Failed to get Kounta Customer %s", res.Status)
+}
+
+// GetProduct will return a single product of the company, a NotFoundError when there is no such product
+func (v *Kounta) GetProduct(token string, company string, productID int64) (*KountaProduct, error) {
+	info := RequestInfo{Endpoint: "GetProduct", Company: company}
+	res, rawResBody, err := v.call(info, "GET", token, v.endpoint(fmt.Sprintf(productSingleURL, company, productID)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == 200 {
+		var resp KountaProduct
+
+		err = json.Unmarshal(rawResBody, &resp)
+		if err != nil {
+			return nil, err
+		}
+		return &resp, nil
+	}
+	if res.StatusCode == http.StatusNotFound {
+		return nil, &NotFoundError{Resource: "Product", Status: res.Status}
+	}
+	return nil, fmt.Errorf("Failed to get Kounta Product %s", res.Status)
+}
+
+// GetStatus will return the account status of the company
+func (v *Kounta) GetStatus(token string, company string) (*CompanyStatus, error) {
+	info := RequestInfo{Endpoint: "GetStatus", Company: company}
+	res, rawResBody, err := v.call(info, "GET", token, v.endpoint(fmt.Sprintf(companyStatus, company)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == 200 {
+		var resp CompanyStatus
+
+		err = json.Unmarshal(rawResBody, &resp)
+		if err != nil {
+			return nil, err
+		}
+		return &resp, nil
+	}
+	return nil, fmt.Errorf("Failed to get Kounta Company Status %s", res.Status)
+}
+
+// apiBase will parse the BaseURL of the client, defaulting to the Kounta api
+func (v *Kounta) apiBase() (*url.URL, error) {
+	base := v.BaseURL
+	if base == "" {
+		base = baseURL
+	}
+
+	u, err := url.ParseRequestURI(base)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("Invalid Kounta BaseURL %q", base)
+	}
+	return u, nil
+}
+
+// endpoint will return the full url for a Kounta api path, keeping any path the BaseURL has.
+// It returns an empty url when the BaseURL is invalid, and send reports the error.
+func (v *Kounta) endpoint(p string) string {
+	u, err := v.apiBase()
+	if err != nil {
+		return ""
+	}
+
+	u.Path = path.Join("/", u.Path, p)
+	return fmt.Sprintf("%v", u)
+}
+
+// call will send an authenticated request to Kounta, encoding body as json when it is not nil
+func (v *Kounta) call(info RequestInfo, method string, token string, urlStr string, body interface{}) (*http.Response, []byte, error) {
+	r, err := v.newRequest(context.Background(), info, method, token, urlStr, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return v.send(r)
+}
+
+// newRequest will create an authenticated request to Kounta, encoding body as json when it is not nil
+func (v *Kounta) newRequest(ctx context.Context, info RequestInfo, method string, token string, urlStr string, body interface{}) (*http.Request, error) {
+	var b []byte
+	if body != nil {
+		var err error
+		b, err = json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	r, err := http.NewRequestWithContext(ctx, method, urlStr, bytes.NewBuffer(b))
+	if err != nil {
+		return nil, err
 	}
 
 	r.Header = http.Header(make(map[string][]string))
 	r.Header.Set("Accept", "application/json")
 	r.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("Content-Length", strconv.Itoa(len(b)))
+	}
 
-	res, err := client.Do(r)
+	return withRequestInfo(r, info), nil
+}
+
+// tokenRequest will post an OAuth form to the Kounta token endpoint
+func (v *Kounta) tokenRequest(info RequestInfo, data url.Values) (*http.Response, []byte, error) {
+	body := data.Encode()
+
+	r, err := http.NewRequest("POST", v.endpoint(tokenURL), bytes.NewBufferString(body))
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
+	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Add("Content-Length", strconv.Itoa(len(body)))
+
+	return v.send(withRequestInfo(r, info))
+}
+
+// send will pass a request through the middleware to Kounta and return the response with its body
+func (v *Kounta) send(r *http.Request) (*http.Response, []byte, error) {
+	if _, err := v.apiBase(); err != nil {
+		return nil, nil, err
+	}
+
+	if v.Limiter != nil {
+		start := time.Now()
+		if err := v.Limiter.Wait(r.Context()); err != nil {
+			return nil, nil, err
+		}
+
+		info := RequestInfoFrom(r)
+		info.Waited = time.Since(start)
+		r = withRequestInfo(r, info)
+	}
+
+	client := &http.Client{Timeout: v.Timeout}
+	client.CheckRedirect = checkRedirectFunc
+
+	res, err := v.chain(client).Do(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+
 	rawResBody, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
-	if res.StatusCode == 200 {
+	return res, rawResBody, nil
+}
 
-		fmt.Println(string(rawResBody))
-		if err != nil {
-			return err
+// createdID will return the id of a resource created by a POST, read from the Location header or the response body
+func createdID(res *http.Response, rawResBody []byte) (int64, error) {
+	if loc := res.Header.Get("Location"); loc != "" {
+		name := path.Base(loc)
+		name = strings.TrimSuffix(name, path.Ext(name))
+		if id, err := strconv.ParseInt(name, 10, 64); err == nil {
+			return id, nil
 		}
-		return nil
 	}
-	fmt.Println(string(rawResBody))
-	return fmt.Errorf("Failed to get Kounta Sale %s", res.Status)
 
+	resp := struct {
+		ID int64 `json:"id"`
+	}{}
+	if err := json.Unmarshal(rawResBody, &resp); err != nil || resp.ID == 0 {
+		return 0, fmt.Errorf("Failed to read Kounta created id %s", res.Status)
+	}
+	return resp.ID, nil
+}
+
+// sleepContext will wait for d, returning the context's error if it is done first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 func checkRedirectFunc(req *http.Request, via []*http.Request) error {
@@ -0,0 +1,165 @@
+package gokounta
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCreatedOrdersBounded(t *testing.T) {
+	limit := createdOrderLimit
+	createdOrderLimit = 3
+	defer func() { createdOrderLimit = limit }()
+
+	now := time.Now()
+	v := &Kounta{createdOrders: map[string]rememberedOrder{
+		"expired": {id: 1, at: now.Add(-createdOrderTTL - time.Minute)},
+		"old":     {id: 2, at: now.Add(-time.Hour)},
+		"new":     {id: 3, at: now.Add(-time.Minute)},
+	}}
+
+	if _, ok := v.createdOrder("expired"); ok {
+		t.Errorf("expired key still returned")
+	}
+
+	v.setCreatedOrder("a", 4)
+	if _, ok := v.createdOrders["expired"]; ok || len(v.createdOrders) != 3 {
+		t.Errorf("holding %v, want the expired key dropped", v.createdOrders)
+	}
+
+	v.setCreatedOrder("b", 5)
+	if _, ok := v.createdOrder("old"); ok {
+		t.Errorf("oldest key still remembered once the limit was reached")
+	}
+	for key, want := range map[string]int64{"new": 3, "a": 4, "b": 5} {
+		if id, ok := v.createdOrder(key); !ok || id != want {
+			t.Errorf("createdOrder(%s) = %d, %v, want %d", key, id, ok, want)
+		}
+	}
+
+	v.setCreatedOrder("", 6)
+	if _, ok := v.createdOrder(""); ok {
+		t.Errorf("empty key remembered")
+	}
+}
+
+func TestTransferStockReversal(t *testing.T) {
+	var mu sync.Mutex
+	adjustments := map[string][]StockAdjustment{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var a StockAdjustment
+		if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		site := strings.Split(r.URL.Path, "/")[5]
+
+		mu.Lock()
+		adjustments[site] = append(adjustments[site], a)
+		mu.Unlock()
+
+		if site == "2" {
+			http.Error(w, "site closed", http.StatusUnprocessableEntity)
+		}
+	}))
+	defer server.Close()
+
+	v := &Kounta{BaseURL: server.URL}
+	if err := v.TransferStock("token", "1", "1", "2", 5, 3, "to the bar"); err == nil {
+		t.Fatalf("TransferStock succeeded, want the destination error")
+	}
+
+	from := adjustments["1"]
+	if len(from) != 2 {
+		t.Fatalf("source adjustments %+v, want the removal and its reversal", from)
+	}
+	if from[0].Reason != StockReasonTransferOut || from[0].Quantity != -3 {
+		t.Errorf("removal %+v, want %s of -3", from[0], StockReasonTransferOut)
+	}
+	if from[1].Reason != StockReasonTransferReversed || from[1].Quantity != 3 {
+		t.Errorf("reversal %+v, want %s of 3", from[1], StockReasonTransferReversed)
+	}
+}
+
+func TestModifierLookupUsesLatestToken(t *testing.T) {
+	var mu sync.Mutex
+	seen := []string{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.Header.Get("Authorization"))
+		mu.Unlock()
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	token := "first"
+	lookup := (&Kounta{BaseURL: server.URL}).NewModifierLookup(func() string { return token }, "1", 0)
+
+	if _, _, err := lookup.Modifier(1); err != nil {
+		t.Fatalf("Modifier error %v", err)
+	}
+	token = "second"
+	lookup.Invalidate()
+	if _, _, err := lookup.Modifier(1); err != nil {
+		t.Fatalf("Modifier after refresh error %v", err)
+	}
+
+	if len(seen) != 2 || !strings.HasSuffix(seen[0], "first") || !strings.HasSuffix(seen[1], "second") {
+		t.Errorf("authorization headers %v, want the token at each load", seen)
+	}
+}
+
+func TestBaseURL(t *testing.T) {
+	var mu sync.Mutex
+	paths := []string{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		baseURL string
+		path    string
+		wantErr bool
+	}{
+		{name: "host only", baseURL: server.URL, path: "/v1/companies/1/sites"},
+		{name: "path prefix", baseURL: server.URL + "/kounta", path: "/kounta/v1/companies/1/sites"},
+		{name: "path prefix with slash", baseURL: server.URL + "/kounta/", path: "/kounta/v1/companies/1/sites"},
+		{name: "malformed", baseURL: "://api.kounta.com", wantErr: true},
+		{name: "no host", baseURL: "api.kounta.com", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		mu.Lock()
+		paths = paths[:0]
+		mu.Unlock()
+
+		_, err := (&Kounta{BaseURL: tt.baseURL}).GetSites("token", "1")
+		if tt.wantErr {
+			if err == nil || !strings.Contains(err.Error(), "Invalid Kounta BaseURL") {
+				t.Errorf("%s: GetSites error %v, want an invalid BaseURL error", tt.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: GetSites error %v", tt.name, err)
+			continue
+		}
+		mu.Lock()
+		if len(paths) != 1 || paths[0] != tt.path {
+			t.Errorf("%s: requested %v, want %s", tt.name, paths, tt.path)
+		}
+		mu.Unlock()
+	}
+}
@@ -0,0 +1,67 @@
+package gokounta
+
+import "fmt"
+
+//StockReason is the reason code for a stock adjustment
+type StockReason string
+
+//Stock adjustment reasons
+const (
+	StockReasonReceived    StockReason = "received"
+	StockReasonStocktake   StockReason = "stocktake"
+	StockReasonDamaged     StockReason = "damaged"
+	StockReasonWaste       StockReason = "waste"
+	StockReasonTheft       StockReason = "theft"
+	StockReasonReturned    StockReason = "returned"
+	StockReasonTransferIn  StockReason = "transfer_in"
+	StockReasonTransferOut StockReason = "transfer_out"
+	// StockReasonTransferReversed puts back stock taken out for a transfer that could not be completed
+	StockReasonTransferReversed StockReason = "transfer_reversed"
+)
+
+//StockLevel is the stock on hand of a product at a Kounta site
+type StockLevel struct {
+	ProductID         int64   `json:"id"`
+	Stock             float64 `json:"stock"`
+	LowStockThreshold float64 `json:"low_stock_threshold"`
+}
+
+//StockLevels is the struct for a list of StockLevel
+type StockLevels []StockLevel
+
+//StockAdjustment is the request struct for changing the stock on hand of a product
+type StockAdjustment struct {
+	ProductID int64       `json:"product_id"`
+	Quantity  float64     `json:"quantity"`
+	Reason    StockReason `json:"reason"`
+	Notes     string      `json:"notes,omitempty"`
+}
+
+// IsLow will return true when the stock is at or below its low stock threshold
+func (s *StockLevel) IsLow() bool {
+	return s.LowStockThreshold > 0 && s.Stock <= s.LowStockThreshold
+}
+
+// Low will return the stock levels that are at or below their low stock threshold
+func (levels StockLevels) Low() StockLevels {
+	low := StockLevels{}
+	for _, s := range levels {
+		if s.IsLow() {
+			low = append(low, s)
+		}
+	}
+	return low
+}
+
+// Validate will check the adjustment can be sent to Kounta
+func (a *StockAdjustment) Validate() error {
+	switch {
+	case a.ProductID <= 0:
+		return fmt.Errorf("Invalid Kounta Stock Adjustment: missing product id")
+	case a.Quantity == 0:
+		return fmt.Errorf("Invalid Kounta Stock Adjustment: quantity is zero")
+	case a.Reason == "":
+		return fmt.Errorf("Invalid Kounta Stock Adjustment: missing reason")
+	}
+	return nil
+}
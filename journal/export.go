@@ -0,0 +1,45 @@
+package journal
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+)
+
+// csvHeader is the header row written by WriteCSV
+var csvHeader = []string{"date", "account", "description", "debit", "credit"}
+
+// WriteCSV will write the journal as csv with one row per line
+func (j *Journal) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	currency := j.Currency
+	if currency == "" {
+		currency = defaultCurrency
+	}
+	for _, l := range j.Lines {
+		row := []string{j.Date, l.Account, l.Description, "", ""}
+		if l.Debit != 0 {
+			row[3] = l.Debit.Format(currency)
+		}
+		if l.Credit != 0 {
+			row[4] = l.Credit.Format(currency)
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON will write the journal as json
+func (j *Journal) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(j)
+}
@@ -0,0 +1,179 @@
+// Package journal turns Kounta orders into balanced double-entry journals for accounting packages
+package journal
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/albimcleod/gokounta"
+)
+
+// defaultCurrency is used to round journal lines when no currency is given
+const defaultCurrency = gokounta.Currency("AUD")
+
+//Options controls how orders are posted
+type Options struct {
+	// Currency is used to round each account to minor units, defaulting to AUD
+	Currency gokounta.Currency
+	// ProductCategory looks up the category of a product for the sales account, nil uses the default account
+	ProductCategory func(productID int64) (gokounta.Category, bool)
+	// ModifierPrices holds the gross unit price of modifiers, used when allocating order discounts
+	ModifierPrices map[int]gokounta.Money
+}
+
+//Line is a single debit or credit to a ledger account
+type Line struct {
+	Account     string         `json:"account"`
+	Description string         `json:"description"`
+	Debit       gokounta.Money `json:"debit"`
+	Credit      gokounta.Money `json:"credit"`
+}
+
+//Journal is the balanced journal of a day's orders
+type Journal struct {
+	Date     string            `json:"date"`
+	Currency gokounta.Currency `json:"currency"`
+	Lines    []Line            `json:"lines"`
+	// Suspense lists the orders posted to the suspense account
+	Suspense []SuspenseOrder `json:"suspense,omitempty"`
+}
+
+//SuspenseOrder is an order whose payments did not match its total
+type SuspenseOrder struct {
+	OrderID int64 `json:"order_id"`
+	// Difference is the payments less the order total, negative when the order is underpaid
+	Difference gokounta.Money `json:"difference"`
+}
+
+// posting is the running balance of one account, debits positive and credits negative
+type posting struct {
+	account     string
+	description string
+	amount      gokounta.Money
+}
+
+// Build will post the orders to the mapped accounts and return a balanced journal.
+// Sales are credited before discount by category, discounts debited, tax credited by tax code and
+// payments debited to each method's clearing account. An order whose payments differ from its total by more than
+// one minor unit is posted to the suspense account, or fails the build when no suspense account is mapped.
+// The rounding account only takes what is left after rounding, at most one minor unit per order.
+func Build(date string, orders []gokounta.Order, mapping *AccountMapping, opts Options) (*Journal, error) {
+	if err := mapping.Validate(); err != nil {
+		return nil, err
+	}
+
+	currency := opts.Currency
+	if currency == "" {
+		currency = defaultCurrency
+	}
+
+	postings := map[string]*posting{}
+	keys := []string{}
+	post := func(account string, description string, amount gokounta.Money) {
+		key := account + "\x00" + description
+		p, ok := postings[key]
+		if !ok {
+			p = &posting{account: account, description: description}
+			postings[key] = p
+			keys = append(keys, key)
+		}
+		p.amount += amount
+	}
+
+	j := &Journal{Date: date, Currency: currency}
+	unit := gokounta.NewMoney(1, currency)
+
+	for _, order := range orders {
+		allocs := order.AllocateVariations(opts.ModifierPrices, currency)
+		total, paid := gokounta.Money(0), gokounta.Money(0)
+
+		for i, item := range order.Items {
+			a := allocs[i]
+
+			category := gokounta.Category{}
+			if opts.ProductCategory != nil {
+				category, _ = opts.ProductCategory(item.Product.ID)
+			}
+
+			discountNet := a.Discount.MulFrac(a.Net, a.Gross)
+
+			salesName := "Sales"
+			if category.Name != "" {
+				salesName = "Sales - " + category.Name
+			}
+			post(mapping.Sales.Account(strconv.Itoa(category.ID)), salesName, -(a.Net + discountNet))
+			post(mapping.Discounts, "Discounts", discountNet)
+
+			code, name, _ := item.TaxCode()
+			taxName := "Tax"
+			if name != "" {
+				taxName = "Tax - " + name
+			}
+			post(mapping.Tax.Account(code), taxName, -a.Tax)
+			total += a.Gross
+		}
+
+		for _, payment := range order.Payments {
+			key := strconv.FormatInt(payment.Method.ID, 10)
+			post(mapping.Payments.Account(key), "Payments - "+payment.Method.Name, payment.Amount-payment.Change)
+			paid += payment.Amount - payment.Change
+		}
+
+		if diff := paid - total; diff.Abs() > unit {
+			if mapping.Suspense == "" {
+				return nil, fmt.Errorf("Kounta Order %v payments of %v do not match its total of %v", order.ID, paid, total)
+			}
+			post(mapping.Suspense, "Suspense", -diff)
+			j.Suspense = append(j.Suspense, SuspenseOrder{OrderID: order.ID, Difference: diff})
+		}
+	}
+
+	balance := gokounta.Money(0)
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		p := postings[key]
+		amount := p.amount.Round(currency)
+		if amount == 0 {
+			continue
+		}
+		balance += amount
+		j.Lines = append(j.Lines, newLine(p.account, p.description, amount))
+	}
+
+	if limit := unit.Mul(float64(len(orders))); balance.Abs() > limit {
+		return nil, fmt.Errorf("Journal for %s is out of balance by %v after rounding", date, balance)
+	}
+	if balance != 0 {
+		j.Lines = append(j.Lines, newLine(mapping.Rounding, "Rounding", -balance))
+	}
+
+	return j, nil
+}
+
+// Totals will return the total debits and credits of the journal
+func (j *Journal) Totals() (gokounta.Money, gokounta.Money) {
+	debit, credit := gokounta.Money(0), gokounta.Money(0)
+	for _, l := range j.Lines {
+		debit += l.Debit
+		credit += l.Credit
+	}
+	return debit, credit
+}
+
+// Balanced will return true when debits equal credits
+func (j *Journal) Balanced() bool {
+	debit, credit := j.Totals()
+	return debit == credit
+}
+
+func newLine(account string, description string, amount gokounta.Money) Line {
+	l := Line{Account: account, Description: description}
+	if amount < 0 {
+		l.Credit = -amount
+	} else {
+		l.Debit = amount
+	}
+	return l
+}
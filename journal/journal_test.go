@@ -0,0 +1,128 @@
+package journal
+
+import (
+	"testing"
+
+	"github.com/albimcleod/gokounta"
+)
+
+func testMapping() *AccountMapping {
+	return &AccountMapping{
+		Sales:     AccountMap{Default: "200"},
+		Tax:       AccountMap{Default: "820"},
+		Payments:  AccountMap{Default: "090"},
+		Discounts: "210",
+		Rounding:  "860",
+	}
+}
+
+func testOrder(id int64, net, tax, paid float64, items int) gokounta.Order {
+	order := gokounta.Order{ID: id, Total: gokounta.MoneyFromFloat((net + tax) * float64(items))}
+	for i := 0; i < items; i++ {
+		order.Items = append(order.Items, gokounta.OrderLine{
+			Quantity:     1,
+			LineTotal:    gokounta.MoneyFromFloat(net),
+			LineTotalTax: gokounta.MoneyFromFloat(tax),
+		})
+	}
+	if paid != 0 {
+		order.Payments = []gokounta.OrderPayment{{Amount: gokounta.MoneyFromFloat(paid), Method: gokounta.OrderPaymentMethod{ID: 1, Name: "Cash"}}}
+	}
+	return order
+}
+
+func TestBuild(t *testing.T) {
+	withSuspense := testMapping()
+	withSuspense.Suspense = "899"
+
+	tests := []struct {
+		name     string
+		orders   []gokounta.Order
+		mapping  *AccountMapping
+		wantErr  bool
+		rounding gokounta.Money
+		suspense []SuspenseOrder
+	}{
+		{
+			name:    "paid sale",
+			orders:  []gokounta.Order{testOrder(1, 100, 10, 110, 1)},
+			mapping: testMapping(),
+		},
+		{
+			name:     "cent of rounding",
+			orders:   []gokounta.Order{testOrder(1, 100, 10, 110.01, 1)},
+			mapping:  testMapping(),
+			rounding: gokounta.MoneyFromFloat(-0.01),
+		},
+		{
+			name:    "unpaid order fails without a suspense account",
+			orders:  []gokounta.Order{testOrder(1, 100, 10, 0, 1)},
+			mapping: testMapping(),
+			wantErr: true,
+		},
+		{
+			name:     "unpaid order goes to suspense",
+			orders:   []gokounta.Order{testOrder(1, 100, 10, 110, 1), testOrder(2, 100, 10, 0, 1)},
+			mapping:  withSuspense,
+			suspense: []SuspenseOrder{{OrderID: 2, Difference: gokounta.MoneyFromFloat(-110)}},
+		},
+		{
+			name:     "overpaid order goes to suspense",
+			orders:   []gokounta.Order{testOrder(3, 100, 10, 120, 1)},
+			mapping:  withSuspense,
+			suspense: []SuspenseOrder{{OrderID: 3, Difference: gokounta.MoneyFromFloat(10)}},
+		},
+		{
+			name:    "paid refund",
+			orders:  []gokounta.Order{testOrder(4, -100, -10, -110, 1)},
+			mapping: testMapping(),
+		},
+	}
+
+	for _, tt := range tests {
+		j, err := Build("2024-01-02", tt.orders, tt.mapping, Options{})
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: Build() = %+v, want error", tt.name, j.Lines)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Build() error %v", tt.name, err)
+			continue
+		}
+
+		if !j.Balanced() {
+			debit, credit := j.Totals()
+			t.Errorf("%s: debits %v credits %v do not balance", tt.name, debit, credit)
+		}
+
+		rounding, suspense := gokounta.Money(0), gokounta.Money(0)
+		for _, l := range j.Lines {
+			switch l.Account {
+			case tt.mapping.Rounding:
+				rounding += l.Debit - l.Credit
+			case tt.mapping.Suspense:
+				suspense += l.Debit - l.Credit
+			}
+		}
+		if rounding != tt.rounding {
+			t.Errorf("%s: rounding %v, want %v", tt.name, rounding, tt.rounding)
+		}
+
+		if len(j.Suspense) != len(tt.suspense) {
+			t.Errorf("%s: suspense orders %+v, want %+v", tt.name, j.Suspense, tt.suspense)
+			continue
+		}
+		want := gokounta.Money(0)
+		for i := range tt.suspense {
+			if j.Suspense[i] != tt.suspense[i] {
+				t.Errorf("%s: suspense order %+v, want %+v", tt.name, j.Suspense[i], tt.suspense[i])
+			}
+			want -= tt.suspense[i].Difference
+		}
+		if suspense != want {
+			t.Errorf("%s: suspense account %v, want %v", tt.name, suspense, want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/albimcleod/gokounta"
+)
+
+//AccountMap maps keys such as category ids to ledger account codes
+type AccountMap struct {
+	Default  string            `json:"default"`
+	Accounts map[string]string `json:"accounts"`
+}
+
+//AccountMapping is the ledger accounts each part of a sale is posted to
+type AccountMapping struct {
+	// Sales is keyed by category id
+	Sales AccountMap `json:"sales"`
+	// Tax is keyed by tax code
+	Tax AccountMap `json:"tax"`
+	// Payments is keyed by payment method id, the clearing account for each method
+	Payments  AccountMap `json:"payments"`
+	Discounts string     `json:"discounts"`
+	Rounding  string     `json:"rounding"`
+	// Suspense takes orders whose payments do not match their total, optional
+	Suspense string `json:"suspense,omitempty"`
+}
+
+// Account will return the account for a key, falling back to the default
+func (m AccountMap) Account(key string) string {
+	if a, ok := m.Accounts[key]; ok && a != "" {
+		return a
+	}
+	return m.Default
+}
+
+// LoadMapping will read an account mapping from a json file
+func LoadMapping(path string) (*AccountMapping, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadMapping(f)
+}
+
+// ReadMapping will read an account mapping from json
+func ReadMapping(r io.Reader) (*AccountMapping, error) {
+	m := &AccountMapping{}
+	if err := json.NewDecoder(r).Decode(m); err != nil {
+		return nil, err
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Validate will check every part of a sale has somewhere to be posted
+func (m *AccountMapping) Validate() error {
+	switch {
+	case m.Sales.Default == "":
+		return fmt.Errorf("Account mapping is missing a default sales account")
+	case m.Tax.Default == "":
+		return fmt.Errorf("Account mapping is missing a default tax account")
+	case m.Payments.Default == "":
+		return fmt.Errorf("Account mapping is missing a default payments account")
+	case m.Discounts == "":
+		return fmt.Errorf("Account mapping is missing a discounts account")
+	case m.Rounding == "":
+		return fmt.Errorf("Account mapping is missing a rounding account")
+	}
+	return nil
+}
+
+// UseLedgerCodes will map payment methods and taxes to the ledger codes set up in Kounta.
+// Accounts already in the mapping are kept.
+func (m *AccountMapping) UseLedgerCodes(methods gokounta.PaymentMethods, taxes gokounta.Taxes) {
+	for _, method := range methods {
+		if method.LedgerCode == "" {
+			continue
+		}
+		if m.Payments.Accounts == nil {
+			m.Payments.Accounts = map[string]string{}
+		}
+		key := strconv.FormatInt(method.ID, 10)
+		if _, ok := m.Payments.Accounts[key]; !ok {
+			m.Payments.Accounts[key] = method.LedgerCode
+		}
+	}
+
+	for _, tax := range taxes {
+		if tax.LedgerCode == "" {
+			continue
+		}
+		if m.Tax.Accounts == nil {
+			m.Tax.Accounts = map[string]string{}
+		}
+		if _, ok := m.Tax.Accounts[tax.Code]; !ok {
+			m.Tax.Accounts[tax.Code] = tax.LedgerCode
+		}
+	}
+}
@@ -0,0 +1,17 @@
+module github.com/albimcleod/gokounta/kountaotel
+
+go 1.20
+
+require (
+	github.com/albimcleod/gokounta v0.0.0-00010101000000-000000000000
+	go.opentelemetry.io/otel v1.24.0
+	go.opentelemetry.io/otel/metric v1.24.0
+	go.opentelemetry.io/otel/trace v1.24.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.1 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+)
+
+replace github.com/albimcleod/gokounta => ../
@@ -0,0 +1,156 @@
+// Package kountaotel records OpenTelemetry spans and metrics for Kounta API calls, import it only when tracing is wanted
+package kountaotel
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/albimcleod/gokounta"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName names the tracer and meter
+const instrumentationName = "github.com/albimcleod/gokounta/kountaotel"
+
+//Options sets the providers used, the global providers are used when nil
+type Options struct {
+	TracerProvider trace.TracerProvider
+	MeterProvider  metric.MeterProvider
+	Propagator     propagation.TextMapPropagator
+}
+
+type instruments struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+
+	requests metric.Int64Counter
+	errors   metric.Int64Counter
+	duration metric.Float64Histogram
+	waited   metric.Float64Histogram
+}
+
+// Instrument will add tracing and metrics middleware to the client
+func Instrument(client *gokounta.Kounta, opts Options) error {
+	mw, err := Middleware(opts)
+	if err != nil {
+		return err
+	}
+	client.Use(mw)
+	return nil
+}
+
+// Middleware will create client middleware that starts a span and records metrics for every Kounta call
+func Middleware(opts Options) (gokounta.Middleware, error) {
+	i, err := newInstruments(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next gokounta.Doer) gokounta.Doer {
+		return gokounta.DoerFunc(func(r *http.Request) (*http.Response, error) {
+			return i.do(next, r)
+		})
+	}, nil
+}
+
+func newInstruments(opts Options) (*instruments, error) {
+	tp := opts.TracerProvider
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	mp := opts.MeterProvider
+	if mp == nil {
+		mp = otel.GetMeterProvider()
+	}
+	prop := opts.Propagator
+	if prop == nil {
+		prop = otel.GetTextMapPropagator()
+	}
+
+	meter := mp.Meter(instrumentationName)
+	i := &instruments{
+		tracer:     tp.Tracer(instrumentationName),
+		propagator: prop,
+	}
+
+	var err error
+	if i.requests, err = meter.Int64Counter("kounta.client.requests",
+		metric.WithDescription("Kounta API requests sent")); err != nil {
+		return nil, err
+	}
+	if i.errors, err = meter.Int64Counter("kounta.client.errors",
+		metric.WithDescription("Kounta API requests that failed, by error class")); err != nil {
+		return nil, err
+	}
+	if i.duration, err = meter.Float64Histogram("kounta.client.duration",
+		metric.WithDescription("Duration of Kounta API requests"), metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	if i.waited, err = meter.Float64Histogram("kounta.client.rate_limit.wait",
+		metric.WithDescription("Time Kounta API requests were held by the rate limiter"), metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+
+	return i, nil
+}
+
+func (i *instruments) do(next gokounta.Doer, r *http.Request) (*http.Response, error) {
+	info := gokounta.RequestInfoFrom(r)
+
+	attrs := []attribute.KeyValue{
+		attribute.String("kounta.endpoint", info.Endpoint),
+		attribute.String("http.request.method", r.Method),
+	}
+	if info.Company != "" {
+		attrs = append(attrs, attribute.String("kounta.company", info.Company))
+	}
+	if info.Site != "" {
+		attrs = append(attrs, attribute.String("kounta.site", info.Site))
+	}
+
+	spanAttrs := attrs
+	if info.Page > 0 {
+		spanAttrs = append(spanAttrs, attribute.Int("kounta.page", info.Page))
+	}
+	if info.Attempt > 1 {
+		spanAttrs = append(spanAttrs, attribute.Int("kounta.retry_count", info.Attempt-1))
+	}
+
+	ctx, span := i.tracer.Start(r.Context(), "kounta."+info.Endpoint,
+		trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(spanAttrs...))
+	defer span.End()
+
+	r = r.WithContext(ctx)
+	i.propagator.Inject(ctx, propagation.HeaderCarrier(r.Header))
+
+	if info.Waited > 0 {
+		i.waited.Record(ctx, info.Waited.Seconds(), metric.WithAttributes(attrs...))
+	}
+
+	start := time.Now()
+	res, err := next.Do(r)
+	elapsed := time.Since(start)
+
+	if res != nil {
+		span.SetAttributes(attribute.Int("http.response.status_code", res.StatusCode))
+		attrs = append(attrs, attribute.Int("http.response.status_code", res.StatusCode))
+	}
+
+	i.requests.Add(ctx, 1, metric.WithAttributes(attrs...))
+	i.duration.Record(ctx, elapsed.Seconds(), metric.WithAttributes(attrs...))
+
+	if class := gokounta.ErrorClass(res, err); class != "" {
+		i.errors.Add(ctx, 1, metric.WithAttributes(append(attrs, attribute.String("error.type", class))...))
+		if err != nil {
+			span.RecordError(err)
+		}
+		span.SetStatus(codes.Error, class)
+	}
+
+	return res, err
+}
@@ -0,0 +1,182 @@
+// Package kountaprom exposes Prometheus metrics for an order sync built on gokounta, import it only when metrics are wanted
+package kountaprom
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/albimcleod/gokounta"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// namespace prefixes every metric name
+const namespace = "kounta"
+
+//Collector is a prometheus.Collector for sync lag and throughput, webhook deliveries, token refreshes and API errors
+type Collector struct {
+	lastSync      *prometheus.GaugeVec
+	ordersFetched *prometheus.CounterVec
+	syncErrors    *prometheus.CounterVec
+
+	webHooksReceived   *prometheus.CounterVec
+	webHooksRejected   *prometheus.CounterVec
+	webHooksDuplicated *prometheus.CounterVec
+
+	tokenRefreshes *prometheus.CounterVec
+	apiRequests    *prometheus.CounterVec
+	apiErrors      *prometheus.CounterVec
+}
+
+// NewCollector will create a Collector, register it with prometheus.MustRegister
+func NewCollector() *Collector {
+	return &Collector{
+		lastSync: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "sync_last_success_timestamp_seconds",
+			Help:      "Unix time of the last successful order sync of a site.",
+		}, []string{"company", "site"}),
+		ordersFetched: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "sync_orders_fetched_total",
+			Help:      "Orders fetched by order syncs of a site.",
+		}, []string{"company", "site"}),
+		syncErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "sync_errors_total",
+			Help:      "Order syncs of a site that failed.",
+		}, []string{"company", "site"}),
+		webHooksReceived: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "webhook_deliveries_received_total",
+			Help:      "Webhook deliveries received, including rejected and duplicate deliveries.",
+		}, []string{"company", "topic"}),
+		webHooksRejected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "webhook_deliveries_rejected_total",
+			Help:      "Webhook deliveries rejected, by reason.",
+		}, []string{"company", "reason"}),
+		webHooksDuplicated: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "webhook_deliveries_duplicated_total",
+			Help:      "Webhook deliveries ignored as already seen.",
+		}, []string{"company", "topic"}),
+		tokenRefreshes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "token_refreshes_total",
+			Help:      "OAuth token refreshes, by result.",
+		}, []string{"result"}),
+		apiRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "api_requests_total",
+			Help:      "Kounta API requests sent.",
+		}, []string{"endpoint", "company"}),
+		apiErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "api_errors_total",
+			Help:      "Kounta API requests that failed, by error class.",
+		}, []string{"endpoint", "company", "class"}),
+	}
+}
+
+func (c *Collector) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		c.lastSync, c.ordersFetched, c.syncErrors,
+		c.webHooksReceived, c.webHooksRejected, c.webHooksDuplicated,
+		c.tokenRefreshes, c.apiRequests, c.apiErrors,
+	}
+}
+
+// Describe will send the descriptions of every metric
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, m := range c.collectors() {
+		m.Describe(ch)
+	}
+}
+
+// Collect will send the current value of every metric
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, m := range c.collectors() {
+		m.Collect(ch)
+	}
+}
+
+// Instrument will add the collector's middleware to the client
+func (c *Collector) Instrument(client *gokounta.Kounta) {
+	client.Use(c.Middleware())
+}
+
+// Middleware will create client middleware counting API requests, API errors and token refreshes
+func (c *Collector) Middleware() gokounta.Middleware {
+	return func(next gokounta.Doer) gokounta.Doer {
+		return gokounta.DoerFunc(func(r *http.Request) (*http.Response, error) {
+			info := gokounta.RequestInfoFrom(r)
+
+			res, err := next.Do(r)
+
+			class := gokounta.ErrorClass(res, err)
+			c.apiRequests.WithLabelValues(info.Endpoint, info.Company).Inc()
+			if class != "" {
+				c.apiErrors.WithLabelValues(info.Endpoint, info.Company, class).Inc()
+			}
+
+			if info.Endpoint == "RefreshToken" {
+				result := "success"
+				if class != "" {
+					result = "failure"
+				}
+				c.tokenRefreshes.WithLabelValues(result).Inc()
+			}
+
+			return res, err
+		})
+	}
+}
+
+// ObserveSync will record a successful sync of a site that fetched the given number of orders
+func (c *Collector) ObserveSync(company string, site string, orders int) {
+	c.lastSync.WithLabelValues(company, site).Set(float64(time.Now().Unix()))
+	c.ordersFetched.WithLabelValues(company, site).Add(float64(orders))
+}
+
+// ObserveSyncError will record a failed sync of a site
+func (c *Collector) ObserveSyncError(company string, site string) {
+	c.syncErrors.WithLabelValues(company, site).Inc()
+}
+
+// TrackBulk will record the sync of each bulk fetch result as it passes through to the returned channel.
+// The channel is closed once results is closed or the context is done, so a reader that stops early does not leak it.
+func (c *Collector) TrackBulk(ctx context.Context, results <-chan gokounta.BulkResult) <-chan gokounta.BulkResult {
+	out := make(chan gokounta.BulkResult)
+	go func() {
+		defer close(out)
+		for r := range results {
+			if r.Err != nil {
+				c.ObserveSyncError(r.Target.Company, r.Target.SiteID)
+			} else {
+				c.ObserveSync(r.Target.Company, r.Target.SiteID, len(r.Orders))
+			}
+			select {
+			case out <- r:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// WebHookReceived will record a webhook delivery, call it for every delivery before deciding to reject or ignore it
+func (c *Collector) WebHookReceived(company string, topic string) {
+	c.webHooksReceived.WithLabelValues(company, topic).Inc()
+}
+
+// WebHookRejected will record a webhook delivery rejected for the reason, such as a bad signature or body
+func (c *Collector) WebHookRejected(company string, reason string) {
+	c.webHooksRejected.WithLabelValues(company, reason).Inc()
+}
+
+// WebHookDuplicated will record a webhook delivery ignored because it was already processed
+func (c *Collector) WebHookDuplicated(company string, topic string) {
+	c.webHooksDuplicated.WithLabelValues(company, topic).Inc()
+}
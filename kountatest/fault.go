@@ -0,0 +1,105 @@
+package kountatest
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Fault makes matching requests fail or respond slowly
+type Fault struct {
+	// Method and Path select the requests the fault applies to, Path matches when it is contained in the request path.
+	// Empty values match every request.
+	Method string
+	Path   string
+	// Status is returned instead of serving the request when not zero
+	Status int
+	// RetryAfter is sent in the Retry-After header of a 429 status
+	RetryAfter time.Duration
+	// Delay is waited before the request is served or failed
+	Delay time.Duration
+	// Times is how many requests the fault applies to, zero applies to every matching request
+	Times int
+}
+
+// RateLimited will return a fault answering the path with 429 Too Many Requests the given number of times
+func RateLimited(path string, times int) Fault {
+	return Fault{Path: path, Status: http.StatusTooManyRequests, RetryAfter: time.Second, Times: times}
+}
+
+// ServerError will return a fault answering the path with 500 Internal Server Error the given number of times
+func ServerError(path string, times int) Fault {
+	return Fault{Path: path, Status: http.StatusInternalServerError, Times: times}
+}
+
+// Slow will return a fault delaying every response to the path
+func Slow(path string, delay time.Duration) Fault {
+	return Fault{Path: path, Delay: delay}
+}
+
+// Inject will add a fault, faults are checked in the order they were added and the first match applies
+func (s *Server) Inject(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults will remove every fault
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = nil
+}
+
+func (f *Fault) matches(r *http.Request) bool {
+	if f.Method != "" && f.Method != r.Method {
+		return false
+	}
+	return strings.Contains(r.URL.Path, f.Path)
+}
+
+// fault will return the first fault matching the request, using up one of its times, the caller holds the lock
+func (s *Server) fault(r *http.Request) *Fault {
+	for i, f := range s.faults {
+		if !f.matches(r) {
+			continue
+		}
+
+		applied := *f
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		return &applied
+	}
+	return nil
+}
+
+// inject will apply a fault to a request and return true when the response has been written
+func (f *Fault) inject(w http.ResponseWriter, r *http.Request) bool {
+	if f.Delay > 0 {
+		t := time.NewTimer(f.Delay)
+		defer t.Stop()
+
+		select {
+		case <-t.C:
+		case <-r.Context().Done():
+			return true
+		}
+	}
+
+	if f.Status == 0 {
+		return false
+	}
+
+	if f.Status == http.StatusTooManyRequests && f.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(f.RetryAfter/time.Second)))
+	}
+	writeError(w, f.Status, http.StatusText(f.Status))
+	return true
+}
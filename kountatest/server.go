@@ -0,0 +1,475 @@
+// Package kountatest provides an in-memory fake of the Kounta API for testing code built on gokounta
+package kountatest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/albimcleod/gokounta"
+)
+
+var (
+	defaultPageSize = 25
+)
+
+//Server is a fake Kounta API served by httptest, seed it with companies and point a client at URL
+type Server struct {
+	URL string
+	// PageSize is how many products or orders are served per page before X-Next-Page is sent
+	PageSize int
+
+	srv *httptest.Server
+
+	mu        sync.Mutex
+	companies map[int]*Company
+	codes     map[string]int
+	tokens    map[string]int
+	refresh   map[string]int
+	faults    []*Fault
+	requests  []string
+	lastID    int64
+}
+
+// NewServer will start an empty fake, call Close when done
+func NewServer() *Server {
+	s := &Server{
+		PageSize:  defaultPageSize,
+		companies: map[int]*Company{},
+		codes:     map[string]int{},
+		tokens:    map[string]int{},
+		refresh:   map[string]int{},
+	}
+	s.srv = httptest.NewServer(s)
+	s.URL = s.srv.URL
+	return s
+}
+
+// Close will shut the fake down
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// NewClient will create a Kounta client that talks to the fake
+func (s *Server) NewClient(code string) *gokounta.Kounta {
+	v := gokounta.NewClient(code, "client-id", "client-secret", "http://localhost/callback")
+	v.BaseURL = s.URL
+	return v
+}
+
+// Requests will return the method and path of every request received, in order
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.requests...)
+}
+
+// ServeHTTP will apply any matching fault then serve the request from the store
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+	f := s.fault(r)
+	s.mu.Unlock()
+
+	if f != nil && f.inject(w, r) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	parts := strings.Split(strings.TrimSuffix(strings.Trim(r.URL.Path, "/"), ".json"), "/")
+	if len(parts) < 2 || parts[0] != "v1" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	parts = parts[1:]
+
+	if len(parts) == 1 && parts[0] == "token" && r.Method == "POST" {
+		s.serveToken(w, r)
+		return
+	}
+	if len(parts) < 2 || parts[0] != "companies" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	companyID, ok := s.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	if parts[1] == "me" {
+		if len(parts) == 2 && r.Method == "GET" {
+			writeJSON(w, http.StatusOK, s.companies[companyID].Company)
+			return
+		}
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	c := s.company(parts[1])
+	if c == nil {
+		writeError(w, http.StatusNotFound, "company not found")
+		return
+	}
+	if c.Company.ID != companyID {
+		writeError(w, http.StatusForbidden, "token does not belong to company")
+		return
+	}
+
+	s.serveCompany(w, r, c, parts[2:])
+}
+
+// authorize will return the company of the request's bearer token, writing a 401 when it is unknown
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) (int, bool) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	id, ok := s.tokens[token]
+	if !ok || s.companies[id] == nil {
+		writeError(w, http.StatusUnauthorized, "invalid access token")
+		return 0, false
+	}
+	return id, true
+}
+
+func (s *Server) serveToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var (
+		id int
+		ok bool
+	)
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		id, ok = s.codes[r.PostForm.Get("code")]
+	case "refresh_token":
+		token := r.PostForm.Get("refresh_token")
+		if id, ok = s.refresh[token]; ok {
+			delete(s.refresh, token)
+		}
+	}
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "invalid grant")
+		return
+	}
+
+	access, refresh := s.issueTokens(id)
+	writeJSON(w, http.StatusOK, gokounta.TokenResponse{AccessToken: access, RefreshToken: refresh})
+}
+
+func (s *Server) serveCompany(w http.ResponseWriter, r *http.Request, c *Company, parts []string) {
+	// ids in the path are matched as * so each route is a fixed string
+	route := make([]string, len(parts))
+	for i, p := range parts {
+		route[i] = p
+		if _, err := strconv.ParseInt(p, 10, 64); err == nil {
+			route[i] = "*"
+		}
+	}
+
+	switch r.Method + " " + strings.Join(route, "/") {
+	case "GET status":
+		writeJSON(w, http.StatusOK, c.Status)
+	case "GET sites":
+		writeJSON(w, http.StatusOK, c.Sites)
+	case "GET staff":
+		writeJSON(w, http.StatusOK, c.Staff)
+	case "POST staff":
+		s.createStaff(w, r, c)
+	case "GET staff/*", "PUT staff/*":
+		s.serveStaffMember(w, r, c, parts[1])
+	case "GET categories":
+		writeJSON(w, http.StatusOK, c.Categories)
+	case "GET categories/*/products":
+		id, _ := strconv.Atoi(parts[1])
+		products := c.Products[id]
+		start, end := s.page(w, r, len(products))
+		writeJSON(w, http.StatusOK, products[start:end])
+	case "GET sites/*/orders/pending", "GET sites/*/orders/complete":
+		s.serveOrders(w, r, c, parts[1], parts[3] == "pending")
+	case "POST orders":
+		s.createOrder(w, r, c)
+	case "GET orders/*", "PUT orders/*":
+		s.serveOrder(w, r, c, parts[1])
+	case "GET webhooks":
+		writeJSON(w, http.StatusOK, c.WebHooks)
+	case "POST webhooks":
+		s.createWebHook(w, r, c)
+	case "DELETE webhooks/*":
+		s.deleteWebHook(w, c, parts[1])
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (s *Server) createStaff(w http.ResponseWriter, r *http.Request, c *Company) {
+	var staff gokounta.Staff
+	if err := json.NewDecoder(r.Body).Decode(&staff); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	staff.ID = int(s.nextID())
+	c.Staff = append(c.Staff, staff)
+	writeCreated(w, r, int64(staff.ID))
+}
+
+func (s *Server) serveStaffMember(w http.ResponseWriter, r *http.Request, c *Company, id string) {
+	for i := range c.Staff {
+		if strconv.Itoa(c.Staff[i].ID) != id {
+			continue
+		}
+
+		if r.Method == "PUT" {
+			staff := c.Staff[i]
+			if err := json.NewDecoder(r.Body).Decode(&staff); err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			staff.ID = c.Staff[i].ID
+			c.Staff[i] = staff
+		}
+		writeJSON(w, http.StatusOK, c.Staff[i])
+		return
+	}
+	writeError(w, http.StatusNotFound, "staff member not found")
+}
+
+func (s *Server) serveOrders(w http.ResponseWriter, r *http.Request, c *Company, site string, pending bool) {
+	query := r.URL.Query()
+
+	var from, to gokounta.Timestamp
+	if v := query.Get("created_gte"); v != "" {
+		from, _ = gokounta.ParseTimestamp(v)
+	}
+	if v := query.Get("created_lte"); v != "" {
+		to, _ = gokounta.ParseTimestamp(v)
+	}
+
+	orders := []gokounta.Order{}
+	for _, order := range c.Orders {
+		if strconv.FormatInt(order.SiteID, 10) != site {
+			continue
+		}
+		if pending && !order.Status.IsOpen() || !pending && order.Status != gokounta.OrderStatusComplete {
+			continue
+		}
+		if !from.IsZero() && order.SaleDate.Before(from.Time) {
+			continue
+		}
+		if !to.IsZero() && order.SaleDate.After(to.Time) {
+			continue
+		}
+		orders = append(orders, order)
+	}
+
+	start, end := s.page(w, r, len(orders))
+	writeJSON(w, http.StatusOK, orders[start:end])
+}
+
+// newOrder is the request body for creating or updating an order
+type newOrder struct {
+	SiteID         int64                 `json:"site_id"`
+	CreatedAt      *gokounta.Timestamp   `json:"created_at"`
+	Status         gokounta.OrderStatus  `json:"status"`
+	Notes          *string               `json:"notes"`
+	CustomerID     int64                 `json:"customer_id"`
+	PriceVariation float64               `json:"price_variation"`
+	Fulfil         *gokounta.OrderFulfil `json:"fulfil"`
+	Lines          []struct {
+		ProductID      int64          `json:"product_id"`
+		Quantity       float64        `json:"quantity"`
+		UnitPrice      gokounta.Money `json:"unit_price"`
+		PriceVariation float64        `json:"price_variation"`
+		Modifiers      []int          `json:"modifiers"`
+		Notes          string         `json:"notes"`
+	} `json:"lines"`
+	Payments []struct {
+		MethodID int64          `json:"method_id"`
+		Amount   gokounta.Money `json:"amount"`
+	} `json:"payments"`
+}
+
+// apply will copy the lines and payments of the request onto an order and total it
+func (n newOrder) apply(order *gokounta.Order) {
+	if n.Notes != nil {
+		order.Notes = *n.Notes
+	}
+	if n.Lines == nil {
+		return
+	}
+
+	order.Items = nil
+	order.Total = 0
+	for _, l := range n.Lines {
+		line := gokounta.OrderLine{
+			Product:        gokounta.OrderLineProduct{ID: l.ProductID},
+			UnitPrice:      l.UnitPrice,
+			Quantity:       l.Quantity,
+			PriceVariation: l.PriceVariation,
+			Modifiers:      l.Modifiers,
+			Notes:          l.Notes,
+			LineTotal:      l.UnitPrice.Mul(l.Quantity),
+		}
+		order.Items = append(order.Items, line)
+		order.Total += line.LineTotal
+	}
+}
+
+func (s *Server) createOrder(w http.ResponseWriter, r *http.Request, c *Company) {
+	var body newOrder
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if body.SiteID == 0 || len(body.Lines) == 0 {
+		writeError(w, http.StatusUnprocessableEntity, "site_id and lines are required")
+		return
+	}
+
+	order := gokounta.Order{
+		ID:             s.nextID(),
+		SaleDate:       gokounta.NewTimestamp(time.Now()),
+		UpdateDate:     gokounta.NewTimestamp(time.Now()),
+		Status:         body.Status,
+		SiteID:         body.SiteID,
+		PriceVariation: body.PriceVariation,
+		Customer:       gokounta.OrderCustomer{ID: body.CustomerID},
+		Fulfil:         body.Fulfil,
+	}
+	if body.CreatedAt != nil {
+		order.SaleDate = *body.CreatedAt
+	}
+	if order.Status == "" {
+		order.Status = gokounta.OrderStatusPending
+	}
+	body.apply(&order)
+	for i, p := range body.Payments {
+		order.Payments = append(order.Payments, gokounta.OrderPayment{
+			Number: i + 1,
+			Amount: p.Amount,
+			Method: gokounta.OrderPaymentMethod{ID: p.MethodID},
+		})
+	}
+
+	c.Orders = append(c.Orders, order)
+
+	w.Header().Set("Location", s.orderURL(c, order.ID))
+	writeJSON(w, http.StatusCreated, order)
+}
+
+func (s *Server) serveOrder(w http.ResponseWriter, r *http.Request, c *Company, id string) {
+	for i := range c.Orders {
+		order := &c.Orders[i]
+		if strconv.FormatInt(order.ID, 10) != id {
+			continue
+		}
+
+		if r.Method == "PUT" {
+			var body newOrder
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			if body.Status != "" && body.Status != order.Status {
+				if !order.Status.CanTransition(body.Status) {
+					writeError(w, http.StatusUnprocessableEntity, "invalid status transition")
+					return
+				}
+				order.Status = body.Status
+			}
+			if body.Payments != nil {
+				writeError(w, http.StatusUnprocessableEntity, "payments cannot be changed on an existing order")
+				return
+			}
+			if body.CustomerID != 0 {
+				order.Customer = gokounta.OrderCustomer{ID: body.CustomerID}
+			}
+			body.apply(order)
+			order.UpdateDate = gokounta.NewTimestamp(time.Now())
+		}
+
+		writeJSON(w, http.StatusOK, order)
+		return
+	}
+	writeError(w, http.StatusNotFound, "order not found")
+}
+
+func (s *Server) createWebHook(w http.ResponseWriter, r *http.Request, c *Company) {
+	var hook gokounta.WebHook
+	if err := json.NewDecoder(r.Body).Decode(&hook); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	hook.ID = int(s.nextID())
+	c.WebHooks = append(c.WebHooks, hook)
+	writeCreated(w, r, int64(hook.ID))
+}
+
+func (s *Server) deleteWebHook(w http.ResponseWriter, c *Company, id string) {
+	for i, hook := range c.WebHooks {
+		if strconv.Itoa(hook.ID) == id {
+			c.WebHooks = append(c.WebHooks[:i], c.WebHooks[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "webhook not found")
+}
+
+// page will return the bounds of the requested page of n items, setting X-Next-Page when more remain
+func (s *Server) page(w http.ResponseWriter, r *http.Request, n int) (int, int) {
+	size := s.PageSize
+	if size <= 0 {
+		size = defaultPageSize
+	}
+
+	query := r.URL.Query()
+	page, _ := strconv.Atoi(query.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	start := (page - 1) * size
+	if start > n {
+		start = n
+	}
+	end := start + size
+	if end >= n {
+		return start, n
+	}
+
+	query.Set("page", strconv.Itoa(page+1))
+	next := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+	w.Header().Set("X-Next-Page", s.URL+next.String())
+	return start, end
+}
+
+func (s *Server) orderURL(c *Company, id int64) string {
+	return s.URL + "/v1/companies/" + strconv.Itoa(c.Company.ID) + "/orders/" + strconv.FormatInt(id, 10) + ".json"
+}
+
+func writeCreated(w http.ResponseWriter, r *http.Request, id int64) {
+	loc := strings.TrimSuffix(r.URL.Path, ".json") + "/" + strconv.FormatInt(id, 10) + ".json"
+	w.Header().Set("Location", loc)
+	writeJSON(w, http.StatusCreated, map[string]int64{"id": id})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
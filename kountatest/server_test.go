@@ -0,0 +1,306 @@
+package kountatest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/albimcleod/gokounta"
+)
+
+const (
+	testCompany = "1"
+	testSite    = "10"
+)
+
+func newTestServer(orders int) *Server {
+	s := NewServer()
+
+	c := Company{
+		Company: gokounta.Company{ID: 1, Name: "Test"},
+		Sites:   gokounta.Sites{{ID: 10, Name: "Main"}},
+	}
+	for i := 0; i < orders; i++ {
+		c.Orders = append(c.Orders, gokounta.Order{
+			ID:       int64(100 + i),
+			Status:   gokounta.OrderStatusPending,
+			SiteID:   10,
+			SaleDate: gokounta.NewTimestamp(time.Now()),
+		})
+	}
+	s.Seed(c)
+	return s
+}
+
+func testOrder() gokounta.Order {
+	return gokounta.Order{
+		SiteID: 10,
+		Status: gokounta.OrderStatusPending,
+		Items: []gokounta.OrderLine{{
+			Product:   gokounta.OrderLineProduct{ID: 5},
+			Quantity:  2,
+			UnitPrice: gokounta.MoneyFromFloat(4.5),
+		}},
+	}
+}
+
+// countRequests will return how many requests were received with the method and a path containing path
+func countRequests(s *Server, method string, path string) int {
+	n := 0
+	for _, r := range s.Requests() {
+		if strings.HasPrefix(r, method+" ") && strings.Contains(r, path) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestTokenExchange(t *testing.T) {
+	s := newTestServer(0)
+	defer s.Close()
+	s.AuthCode("code", 1)
+
+	v := s.NewClient("code")
+	access, refresh, err := v.AccessToken()
+	if err != nil {
+		t.Fatalf("AccessToken error %v", err)
+	}
+
+	company, err := v.GetCompany(access)
+	if err != nil || company.ID != 1 {
+		t.Fatalf("GetCompany = %+v, %v, want company 1", company, err)
+	}
+
+	access2, _, err := v.RefreshToken(refresh)
+	if err != nil {
+		t.Fatalf("RefreshToken error %v", err)
+	}
+	if access2 == access {
+		t.Errorf("RefreshToken returned the same access token")
+	}
+	if _, err := v.GetSites(access2, testCompany); err != nil {
+		t.Errorf("GetSites with refreshed token error %v", err)
+	}
+
+	if _, _, err := v.RefreshToken(refresh); err == nil {
+		t.Errorf("reusing a refresh token succeeded, want error")
+	}
+	if _, err := v.GetSites("unknown", testCompany); err == nil {
+		t.Errorf("GetSites with an unknown token succeeded, want error")
+	}
+	if _, _, err := s.NewClient("wrong").AccessToken(); err == nil {
+		t.Errorf("AccessToken with an unknown code succeeded, want error")
+	}
+}
+
+func TestOrderPaging(t *testing.T) {
+	s := newTestServer(5)
+	defer s.Close()
+	s.PageSize = 2
+
+	v := s.NewClient("code")
+	token := s.Token(1)
+
+	orders, err := v.GetOrders(token, testCompany, testSite)
+	if err != nil {
+		t.Fatalf("GetOrders error %v", err)
+	}
+	if len(orders) != 5 {
+		t.Errorf("got %d orders, want 5", len(orders))
+	}
+	if n := countRequests(s, "GET", "orders/pending"); n != 3 {
+		t.Errorf("%d page requests, want 3", n)
+	}
+
+	orders, err = v.QueryPendingOrders(token, testCompany, testSite, gokounta.OrderQuery{MaxPages: 1})
+	if err != nil || len(orders) != 2 {
+		t.Errorf("one page = %d orders, %v, want 2", len(orders), err)
+	}
+}
+
+func TestCreateAndUpdateOrder(t *testing.T) {
+	s := newTestServer(0)
+	defer s.Close()
+
+	v := s.NewClient("code")
+	token := s.Token(1)
+
+	id, err := v.CreateOrder(token, testCompany, testOrder(), "key-1")
+	if err != nil {
+		t.Fatalf("CreateOrder error %v", err)
+	}
+
+	// the client remembers the key so the repeat is not sent
+	again, err := v.CreateOrder(token, testCompany, testOrder(), "key-1")
+	if err != nil || again != id {
+		t.Errorf("repeat CreateOrder = %d, %v, want %d", again, err, id)
+	}
+	if n := countRequests(s, "POST", "orders"); n != 1 {
+		t.Errorf("%d creates sent, want 1", n)
+	}
+
+	c, _ := s.Company(1)
+	if len(c.Orders) != 1 {
+		t.Fatalf("fake holds %d orders, want 1", len(c.Orders))
+	}
+	order := c.Orders[0]
+	if order.ID != id || order.Total != gokounta.MoneyFromFloat(9) || !strings.Contains(order.Notes, "ref:key-1") {
+		t.Errorf("created order %+v, want id %d total 9 and the key reference", order, id)
+	}
+
+	// the update carries the lines and customer but never the payments
+	order.Items[0].Quantity = 3
+	order.Customer.ID = 7
+	order.Payments = []gokounta.OrderPayment{{Amount: gokounta.MoneyFromFloat(13.5), Method: gokounta.OrderPaymentMethod{ID: 1}}}
+	if err := v.UpdateOrder(token, testCompany, order); err != nil {
+		t.Fatalf("UpdateOrder error %v", err)
+	}
+	if err := v.CompleteOrder(token, testCompany, &order); err != nil {
+		t.Fatalf("CompleteOrder error %v", err)
+	}
+
+	c, _ = s.Company(1)
+	if got := c.Orders[0]; got.Total != gokounta.MoneyFromFloat(13.5) || got.Status != gokounta.OrderStatusComplete || got.Customer.ID != 7 {
+		t.Errorf("updated order total %v status %s customer %d, want 13.5, COMPLETE and 7", got.Total, got.Status, got.Customer.ID)
+	}
+	if err := v.UpdateOrder(token, testCompany, order); err == nil {
+		t.Errorf("UpdateOrder of a complete order succeeded, want error")
+	}
+}
+
+func TestRateLimitedFault(t *testing.T) {
+	s := newTestServer(1)
+	defer s.Close()
+	s.Inject(RateLimited("orders", 1))
+
+	v := s.NewClient("code")
+	token := s.Token(1)
+
+	// reads are not retried so the 429 reaches the caller
+	if _, err := v.GetOrders(token, testCompany, testSite); err == nil || !strings.Contains(err.Error(), "429") {
+		t.Errorf("GetOrders error %v, want 429", err)
+	}
+	if orders, err := v.GetOrders(token, testCompany, testSite); err != nil || len(orders) != 1 {
+		t.Errorf("GetOrders after the fault = %d orders, %v, want 1", len(orders), err)
+	}
+}
+
+func TestServerErrorOnCreate(t *testing.T) {
+	s := newTestServer(0)
+	defer s.Close()
+
+	v := s.NewClient("code")
+	token := s.Token(1)
+
+	// without a key the failed create may have gone through, so it is not retried
+	s.Inject(Fault{Method: "POST", Path: "orders", Status: 500, Times: 1})
+	if _, err := v.CreateOrder(token, testCompany, testOrder(), ""); err == nil {
+		t.Errorf("CreateOrder without a key succeeded after a 500, want error")
+	}
+	if n := countRequests(s, "POST", "orders"); n != 1 {
+		t.Errorf("%d creates sent without a key, want 1", n)
+	}
+
+	// with a key the order is looked up by its reference before retrying
+	s.Inject(Fault{Method: "POST", Path: "orders", Status: 500, Times: 1})
+	id, err := v.CreateOrder(token, testCompany, testOrder(), "key-2")
+	if err != nil {
+		t.Fatalf("CreateOrder error %v", err)
+	}
+	if n := countRequests(s, "POST", "orders"); n != 3 {
+		t.Errorf("%d creates sent in total, want the failed one retried once", n)
+	}
+	if n := countRequests(s, "GET", "orders/pending"); n != 1 {
+		t.Errorf("%d reference lookups, want 1", n)
+	}
+	if c, _ := s.Company(1); len(c.Orders) != 1 || c.Orders[0].ID != id {
+		t.Errorf("fake holds %+v, want only order %d", c.Orders, id)
+	}
+}
+
+func TestServerErrorFindsCreatedOrder(t *testing.T) {
+	s := newTestServer(0)
+	defer s.Close()
+
+	// an order from an earlier attempt whose response was lost
+	s.Seed(Company{
+		Company: gokounta.Company{ID: 1},
+		Orders: []gokounta.Order{{
+			ID:       42,
+			Status:   gokounta.OrderStatusPending,
+			SiteID:   10,
+			Notes:    "ref:key-3",
+			SaleDate: gokounta.NewTimestamp(time.Now()),
+		}},
+	})
+	s.Inject(ServerError("orders.json", 1))
+
+	v := s.NewClient("code")
+	id, err := v.CreateOrder(s.Token(1), testCompany, testOrder(), "key-3")
+	if err != nil || id != 42 {
+		t.Errorf("CreateOrder = %d, %v, want the existing order 42", id, err)
+	}
+	if n := countRequests(s, "POST", "orders"); n != 1 {
+		t.Errorf("%d creates sent, want no retry once the order was found", n)
+	}
+}
+
+func TestSlowFault(t *testing.T) {
+	s := newTestServer(1)
+	defer s.Close()
+	s.Inject(Slow("orders/pending", time.Second))
+
+	v := s.NewClient("code")
+	v.Timeout = 50 * time.Millisecond
+
+	if _, err := v.GetOrders(s.Token(1), testCompany, testSite); err == nil {
+		t.Errorf("GetOrders succeeded past the client timeout, want error")
+	}
+	if _, err := v.GetSites(s.Token(1), testCompany); err != nil {
+		t.Errorf("GetSites error %v, want only orders slowed", err)
+	}
+
+	s.ClearFaults()
+	if orders, err := v.GetOrders(s.Token(1), testCompany, testSite); err != nil || len(orders) != 1 {
+		t.Errorf("GetOrders after ClearFaults = %d orders, %v, want 1", len(orders), err)
+	}
+}
+
+func TestCreateOrderCancelledDuringBackoff(t *testing.T) {
+	s := newTestServer(0)
+	defer s.Close()
+	s.Inject(Fault{Method: "POST", Path: "orders", Status: 503})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := s.NewClient("code").CreateOrderContext(ctx, s.Token(1), testCompany, testOrder(), "key-4"); err != context.DeadlineExceeded {
+		t.Errorf("CreateOrderContext error %v, want %v", err, context.DeadlineExceeded)
+	}
+	if waited := time.Since(start); waited > 500*time.Millisecond {
+		t.Errorf("CreateOrderContext returned after %v, want it to stop at the deadline", waited)
+	}
+}
+
+func TestPollerRunCancelledDuringPoll(t *testing.T) {
+	s := newTestServer(1)
+	defer s.Close()
+	s.Inject(Slow("orders/pending", 5*time.Second))
+
+	p := gokounta.NewPendingOrderPoller(s.NewClient("code"), s.Token(1), testCompany, testSite)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := p.Run(ctx, make(chan gokounta.PendingOrderEvent, 1), func(err error) {
+		t.Errorf("onError(%v), want the cancelled poll not reported", err)
+	})
+	if err != context.DeadlineExceeded {
+		t.Errorf("Run error %v, want %v", err, context.DeadlineExceeded)
+	}
+	if waited := time.Since(start); waited > time.Second {
+		t.Errorf("Run returned after %v, want the slow poll abandoned", waited)
+	}
+}
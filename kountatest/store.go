@@ -0,0 +1,100 @@
+package kountatest
+
+import (
+	"strconv"
+
+	"github.com/albimcleod/gokounta"
+)
+
+//Company is the data the fake serves for one company
+type Company struct {
+	Company    gokounta.Company
+	Status     gokounta.CompanyStatus
+	Sites      gokounta.Sites
+	Staff      gokounta.Staffs
+	Categories gokounta.Categories
+	// Products are the products of each category id
+	Products map[int]gokounta.KountaProducts
+	// Orders are served as pending or complete by their Status and SiteID
+	Orders   []gokounta.Order
+	WebHooks gokounta.WebHooks
+}
+
+// Seed will add a company to the fake, replacing any company with the same id
+func (s *Server) Seed(c Company) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c.Status.State == "" {
+		c.Status.State = gokounta.CompanyStateActive
+	}
+	if c.Products == nil {
+		c.Products = map[int]gokounta.KountaProducts{}
+	}
+	s.companies[c.Company.ID] = &c
+}
+
+// Company will return a copy of a company as the fake currently holds it, for asserting on changes
+func (s *Server) Company(id int) (Company, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.companies[id]
+	if !ok {
+		return Company{}, false
+	}
+
+	cp := *c
+	cp.Sites = append(gokounta.Sites{}, c.Sites...)
+	cp.Staff = append(gokounta.Staffs{}, c.Staff...)
+	cp.Categories = append(gokounta.Categories{}, c.Categories...)
+	cp.Orders = append([]gokounta.Order{}, c.Orders...)
+	cp.WebHooks = append(gokounta.WebHooks{}, c.WebHooks...)
+	cp.Products = map[int]gokounta.KountaProducts{}
+	for k, v := range c.Products {
+		cp.Products[k] = append(gokounta.KountaProducts{}, v...)
+	}
+	return cp, true
+}
+
+// AuthCode will register an OAuth code that AccessToken can exchange for tokens of the company
+func (s *Server) AuthCode(code string, companyID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.codes[code] = companyID
+}
+
+// Token will issue an access token for the company, for tests that skip the OAuth exchange
+func (s *Server) Token(companyID int) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	access, _ := s.issueTokens(companyID)
+	return access
+}
+
+// issueTokens will create a new access and refresh token pair, the caller holds the lock
+func (s *Server) issueTokens(companyID int) (string, string) {
+	s.lastID++
+	n := strconv.FormatInt(s.lastID, 10)
+
+	access, refresh := "access-"+n, "refresh-"+n
+	s.tokens[access] = companyID
+	s.refresh[refresh] = companyID
+	return access, refresh
+}
+
+// company will return the company by the id in a request path, the caller holds the lock
+func (s *Server) company(id string) *Company {
+	n, err := strconv.Atoi(id)
+	if err != nil {
+		return nil
+	}
+	return s.companies[n]
+}
+
+// nextID will return a new id for a created resource, the caller holds the lock
+func (s *Server) nextID() int64 {
+	s.lastID++
+	return s.lastID
+}
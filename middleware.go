@@ -0,0 +1,95 @@
+package gokounta
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Error classes of failed requests, returned by ErrorClass
+const (
+	ErrorClassNetwork     = "network"
+	ErrorClassTimeout     = "timeout"
+	ErrorClassRateLimited = "rate_limited"
+	ErrorClassAuth        = "auth"
+	ErrorClassClient      = "client"
+	ErrorClassServer      = "server"
+)
+
+//Doer sends a request to Kounta, *http.Client is a Doer
+type Doer interface {
+	Do(r *http.Request) (*http.Response, error)
+}
+
+//DoerFunc is a func used as a Doer
+type DoerFunc func(r *http.Request) (*http.Response, error)
+
+// Do will call the func
+func (f DoerFunc) Do(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+//Middleware wraps the Doer used for every Kounta request
+type Middleware func(next Doer) Doer
+
+//RequestInfo describes the Kounta call a request belongs to
+type RequestInfo struct {
+	// Endpoint is the client method making the request, such as GetSites
+	Endpoint string
+	Company  string
+	Site     string
+	// Page is the page number of a paginated call, starting at 1, or 0 when the call is not paginated
+	Page int
+	// Attempt is the attempt number of a call that retries, starting at 1, or 0 when the call does not retry
+	Attempt int
+	// Waited is how long the request was held by the client's Limiter before being sent
+	Waited time.Duration
+}
+
+type requestInfoKey struct{}
+
+// Use will add middleware to the client, the first middleware added is the outermost
+func (v *Kounta) Use(middleware ...Middleware) {
+	v.middleware = append(v.middleware, middleware...)
+}
+
+// chain will wrap the doer in the client's middleware
+func (v *Kounta) chain(doer Doer) Doer {
+	for i := len(v.middleware) - 1; i >= 0; i-- {
+		doer = v.middleware[i](doer)
+	}
+	return doer
+}
+
+// RequestInfoFrom will return the Kounta call a request belongs to, for use in middleware
+func RequestInfoFrom(r *http.Request) RequestInfo {
+	info, _ := r.Context().Value(requestInfoKey{}).(RequestInfo)
+	return info
+}
+
+func withRequestInfo(r *http.Request, info RequestInfo) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), requestInfoKey{}, info))
+}
+
+// ErrorClass will return the class of a failed request from a Doer, or empty when it succeeded
+func ErrorClass(res *http.Response, err error) string {
+	if err != nil {
+		if ne, ok := err.(net.Error); ok && ne.Timeout() {
+			return ErrorClassTimeout
+		}
+		return ErrorClassNetwork
+	}
+
+	switch {
+	case res.StatusCode == http.StatusTooManyRequests:
+		return ErrorClassRateLimited
+	case res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden:
+		return ErrorClassAuth
+	case res.StatusCode >= 500:
+		return ErrorClassServer
+	case res.StatusCode >= 400:
+		return ErrorClassClient
+	}
+	return ""
+}
@@ -0,0 +1,164 @@
+package gokounta
+
+import (
+	"sync"
+	"time"
+)
+
+//Modifier is an option that can be added to a product on an order line
+type Modifier struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Price Money  `json:"price"`
+}
+
+//OptionSet is the struct for a Kounta modifier option set
+type OptionSet struct {
+	ID            int        `json:"id"`
+	Name          string     `json:"name"`
+	MinSelections int        `json:"min_selections"`
+	MaxSelections int        `json:"max_selections"`
+	Options       []Modifier `json:"options"`
+}
+
+//OptionSets is the struct for a list of OptionSet
+type OptionSets []OptionSet
+
+//ResolvedModifier is a modifier of an order line with its name and price
+type ResolvedModifier struct {
+	ID   int
+	Name string
+	// Price is the price change for one of the modifier
+	Price Money
+	// Quantity is how many of the modifier were sold across the whole line
+	Quantity float64
+	// Known is false when the modifier was not found in the option sets
+	Known bool
+}
+
+//ModifierLookup caches modifiers by id, reloading the option sets once the TTL has passed
+type ModifierLookup struct {
+	TTL time.Duration
+
+	load      func() (OptionSets, error)
+	mu        sync.Mutex
+	modifiers map[int]Modifier
+	loadedAt  time.Time
+}
+
+// NewModifierLookup will create a lookup that loads option sets with the load func.
+// A zero ttl keeps the first load forever.
+func NewModifierLookup(load func() (OptionSets, error), ttl time.Duration) *ModifierLookup {
+	return &ModifierLookup{
+		TTL:  ttl,
+		load: load,
+	}
+}
+
+// SetOptionSets will replace the cached modifiers with those from the option sets
+func (l *ModifierLookup) SetOptionSets(sets OptionSets) {
+	modifiers := map[int]Modifier{}
+	for _, set := range sets {
+		for _, m := range set.Options {
+			modifiers[m.ID] = m
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.modifiers = modifiers
+	l.loadedAt = time.Now()
+}
+
+// Invalidate will drop the cached modifiers so the next lookup reloads them
+func (l *ModifierLookup) Invalidate() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.modifiers = nil
+}
+
+// Modifier will return a modifier by id
+func (l *ModifierLookup) Modifier(id int) (Modifier, bool, error) {
+	modifiers, err := l.current()
+	if err != nil {
+		return Modifier{}, false, err
+	}
+	m, ok := modifiers[id]
+	return m, ok, nil
+}
+
+// Prices will return the price of every modifier by id, for use when allocating discounts
+func (l *ModifierLookup) Prices() (map[int]Money, error) {
+	modifiers, err := l.current()
+	if err != nil {
+		return nil, err
+	}
+
+	prices := make(map[int]Money, len(modifiers))
+	for id, m := range modifiers {
+		prices[id] = m.Price
+	}
+	return prices, nil
+}
+
+// ResolveLine will return the modifiers of a line with their names, prices and quantities
+func (l *ModifierLookup) ResolveLine(line OrderLine) ([]ResolvedModifier, error) {
+	modifiers, err := l.current()
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := []ResolvedModifier{}
+	index := map[int]int{}
+	for _, id := range line.Modifiers {
+		if i, ok := index[id]; ok {
+			resolved[i].Quantity += line.Quantity
+			continue
+		}
+
+		m, ok := modifiers[id]
+		index[id] = len(resolved)
+		resolved = append(resolved, ResolvedModifier{
+			ID:       id,
+			Name:     m.Name,
+			Price:    m.Price,
+			Quantity: line.Quantity,
+			Known:    ok,
+		})
+	}
+	return resolved, nil
+}
+
+// ResolveOrder will fill in the resolved modifiers of every line of the order
+func (l *ModifierLookup) ResolveOrder(order *Order) error {
+	for i := range order.Items {
+		resolved, err := l.ResolveLine(order.Items[i])
+		if err != nil {
+			return err
+		}
+		order.Items[i].ResolvedModifiers = resolved
+	}
+	return nil
+}
+
+// current will return the cached modifiers, loading them when missing or stale
+func (l *ModifierLookup) current() (map[int]Modifier, error) {
+	l.mu.Lock()
+	modifiers := l.modifiers
+	stale := modifiers == nil || (l.TTL > 0 && time.Since(l.loadedAt) > l.TTL)
+	l.mu.Unlock()
+
+	if !stale || l.load == nil {
+		return modifiers, nil
+	}
+
+	sets, err := l.load()
+	if err != nil {
+		return nil, err
+	}
+	l.SetOptionSets(sets)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.modifiers, nil
+}
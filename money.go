@@ -0,0 +1,308 @@
+package gokounta
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// moneyDigits is the number of decimal places Money keeps, enough for Kounta's ex-tax unit prices
+const moneyDigits = 6
+
+var moneyScale = big.NewInt(1000000)
+
+// decimalPattern matches the decimal literals ParseMoney accepts, including json exponents
+var decimalPattern = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)([eE][+-]?\d{1,3})?$`)
+
+//Money is an exact decimal amount, stored as a count of millionths
+type Money int64
+
+//Currency is an ISO 4217 currency code such as AUD
+type Currency string
+
+// currencyDigits holds the minor unit digits of currencies that do not use cents
+var currencyDigits = map[Currency]int{
+	"JPY": 0,
+	"KRW": 0,
+	"VND": 0,
+	"ISK": 0,
+	"BHD": 3,
+	"KWD": 3,
+	"OMR": 3,
+}
+
+// Digits will return the number of minor unit digits of the currency
+func (c Currency) Digits() int {
+	if d, ok := currencyDigits[Currency(strings.ToUpper(string(c)))]; ok {
+		return d
+	}
+	return 2
+}
+
+// NewMoney will create Money from a count of minor units, e.g. cents, of the currency
+func NewMoney(minor int64, c Currency) Money {
+	m := Money(minor)
+	for i := c.Digits(); i < moneyDigits; i++ {
+		m *= 10
+	}
+	return m
+}
+
+// MoneyFromFloat will create Money from a float, rounded to the nearest millionth.
+// Floats beyond the range of Money are clamped to it and NaN is zero.
+func MoneyFromFloat(f float64) Money {
+	return clampMoney(new(big.Rat).SetFloat64(f))
+}
+
+// ParseMoney will create Money from a decimal string such as "12.345", rejecting amounts Money cannot hold
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if !decimalPattern.MatchString(s) {
+		return 0, fmt.Errorf("Invalid money amount %q", s)
+	}
+
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return 0, fmt.Errorf("Invalid money amount %q", s)
+	}
+	return moneyFromRat(r)
+}
+
+// moneyFromRat will round a rational amount half away from zero to the nearest millionth
+func moneyFromRat(r *big.Rat) (Money, error) {
+	if r == nil {
+		return 0, nil
+	}
+
+	n := new(big.Int).Mul(r.Num(), moneyScale)
+	d := r.Denom()
+
+	q, rem := new(big.Int).QuoRem(n, d, new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2)).Cmp(d) >= 0 {
+		if n.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+
+	if !q.IsInt64() {
+		return 0, fmt.Errorf("Money amount %s is out of range", r.FloatString(moneyDigits))
+	}
+	return Money(q.Int64()), nil
+}
+
+// clampMoney will round a rational amount like moneyFromRat, clamping amounts out of range to the largest Money
+func clampMoney(r *big.Rat) Money {
+	m, err := moneyFromRat(r)
+	if err == nil {
+		return m
+	}
+	if r.Sign() < 0 {
+		return Money(math.MinInt64)
+	}
+	return Money(math.MaxInt64)
+}
+
+func (m Money) rat() *big.Rat {
+	return new(big.Rat).SetFrac(big.NewInt(int64(m)), moneyScale)
+}
+
+// Float64 will return the amount as a float, for callers still using float amounts
+func (m Money) Float64() float64 {
+	f, _ := m.rat().Float64()
+	return f
+}
+
+// Minor will return the amount in minor units of the currency, rounded half away from zero
+func (m Money) Minor(c Currency) int64 {
+	r := m.Round(c)
+	for i := c.Digits(); i < moneyDigits; i++ {
+		r /= 10
+	}
+	return int64(r)
+}
+
+// Add will return the sum of the amounts
+func (m Money) Add(o Money) Money {
+	return m + o
+}
+
+// Sub will return the difference of the amounts
+func (m Money) Sub(o Money) Money {
+	return m - o
+}
+
+// Neg will return the amount with its sign flipped
+func (m Money) Neg() Money {
+	return -m
+}
+
+// Abs will return the amount without its sign
+func (m Money) Abs() Money {
+	if m < 0 {
+		return -m
+	}
+	return m
+}
+
+// IsZero will return true when the amount is zero
+func (m Money) IsZero() bool {
+	return m == 0
+}
+
+// Mul will return the amount multiplied by a quantity or ratio, rounded to the nearest millionth
+func (m Money) Mul(f float64) Money {
+	return clampMoney(new(big.Rat).Mul(m.rat(), new(big.Rat).SetFloat64(f)))
+}
+
+// Div will return the amount divided by a quantity or ratio, rounded to the nearest millionth
+func (m Money) Div(f float64) Money {
+	if f == 0 {
+		return 0
+	}
+	return clampMoney(new(big.Rat).Quo(m.rat(), new(big.Rat).SetFloat64(f)))
+}
+
+// RoundTo will round the amount half away from zero to the given number of decimal places
+func (m Money) RoundTo(digits int) Money {
+	if digits >= moneyDigits {
+		return m
+	}
+	if digits < 0 {
+		digits = 0
+	}
+
+	unit := Money(1)
+	for i := digits; i < moneyDigits; i++ {
+		unit *= 10
+	}
+
+	half := unit / 2
+	if m < 0 {
+		return -((-m + half) / unit * unit)
+	}
+	return (m + half) / unit * unit
+}
+
+// Round will round the amount to the minor units of the currency
+func (m Money) Round(c Currency) Money {
+	return m.RoundTo(c.Digits())
+}
+
+// String will return the amount as a decimal without trailing zeros
+func (m Money) String() string {
+	s := m.rat().FloatString(moneyDigits)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(s, "0")
+		s = strings.TrimSuffix(s, ".")
+	}
+	if s == "-0" {
+		s = "0"
+	}
+	return s
+}
+
+// Format will return the amount with the currency's minor unit digits, e.g. 12.50
+func (m Money) Format(c Currency) string {
+	return m.Round(c).rat().FloatString(c.Digits())
+}
+
+// MarshalJSON will write the amount as an exact json number
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON will read the amount from a json number or string without going through a float
+func (m *Money) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), "\"")
+	if s == "" || s == "null" {
+		*m = 0
+		return nil
+	}
+
+	v, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+// SumMoney will return the total of the amounts
+func SumMoney(amounts ...Money) Money {
+	t := Money(0)
+	for _, a := range amounts {
+		t += a
+	}
+	return t
+}
+
+// MulFrac will return the amount multiplied by num/den, rounded to the nearest millionth
+func (m Money) MulFrac(num Money, den Money) Money {
+	if den == 0 {
+		return 0
+	}
+	r := new(big.Rat).Mul(m.rat(), new(big.Rat).SetFrac(big.NewInt(int64(num)), big.NewInt(int64(den))))
+	return clampMoney(r)
+}
+
+// Allocate will split the amount in proportion to the weights so each part is a whole number of the currency's
+// minor units and the parts sum exactly to the amount rounded to the currency.
+// Leftover minor units go to the parts with the largest remainders, and zero weights split evenly.
+func (m Money) Allocate(weights []Money, c Currency) []Money {
+	parts := make([]Money, len(weights))
+	if len(weights) == 0 {
+		return parts
+	}
+
+	total := SumMoney(weights...)
+	if total == 0 {
+		weights = make([]Money, len(weights))
+		for i := range weights {
+			weights[i] = 1
+		}
+		total = Money(len(weights))
+	}
+
+	unit := NewMoney(1, c)
+	minor := m.Minor(c)
+
+	amount := big.NewInt(minor)
+	den := big.NewInt(int64(total))
+	rems := make([]*big.Int, len(weights))
+	allocated := int64(0)
+	minors := make([]int64, len(weights))
+
+	for i, w := range weights {
+		q, r := new(big.Int).QuoRem(new(big.Int).Mul(amount, big.NewInt(int64(w))), den, new(big.Int))
+		minors[i] = q.Int64()
+		rems[i] = r.Abs(r)
+		allocated += minors[i]
+	}
+
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return rems[order[a]].Cmp(rems[order[b]]) > 0
+	})
+
+	step := int64(1)
+	if minor-allocated < 0 {
+		step = -1
+	}
+	for i := 0; allocated != minor; i = (i + 1) % len(order) {
+		minors[order[i]] += step
+		allocated += step
+	}
+
+	for i := range parts {
+		parts[i] = Money(minors[i]) * unit
+	}
+	return parts
+}
@@ -0,0 +1,129 @@
+package gokounta
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Money
+		wantErr bool
+	}{
+		{in: "0", want: 0},
+		{in: "12.5", want: 12500000},
+		{in: "-12.345", want: -12345000},
+		{in: " 7 ", want: 7000000},
+		{in: ".5", want: 500000},
+		{in: "1.5e2", want: 150000000},
+		{in: "0.0000005", want: 1},
+		{in: "-0.0000005", want: -1},
+		{in: "9223372036854.775807", want: Money(math.MaxInt64)},
+		{in: "9223372036854.775808", wantErr: true},
+		{in: "1e30", wantErr: true},
+		{in: "1/3", wantErr: true},
+		{in: "abc", wantErr: true},
+		{in: "", wantErr: true},
+		{in: "1e99999", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseMoney(%q) = %v, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseMoney(%q) error %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseMoney(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyJSONRoundTrip(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: `12.50`, want: `12.5`},
+		{in: `"12.50"`, want: `12.5`},
+		{in: `-0.000001`, want: `-0.000001`},
+		{in: `0`, want: `0`},
+		{in: `null`, want: `0`},
+		{in: `123456.789012`, want: `123456.789012`},
+	}
+
+	for _, tt := range tests {
+		var m Money
+		if err := json.Unmarshal([]byte(tt.in), &m); err != nil {
+			t.Errorf("Unmarshal(%s) error %v", tt.in, err)
+			continue
+		}
+		b, err := json.Marshal(m)
+		if err != nil {
+			t.Errorf("Marshal(%d) error %v", m, err)
+			continue
+		}
+		if string(b) != tt.want {
+			t.Errorf("round trip of %s = %s, want %s", tt.in, b, tt.want)
+		}
+	}
+
+	for _, in := range []string{`1e30`, `"1/3"`, `"12,50"`} {
+		var m Money
+		if err := json.Unmarshal([]byte(in), &m); err == nil {
+			t.Errorf("Unmarshal(%s) = %v, want error", in, m)
+		}
+	}
+}
+
+func TestMoneyRoundAndFormat(t *testing.T) {
+	tests := []struct {
+		m        Money
+		currency Currency
+		want     string
+		minor    int64
+	}{
+		{m: MoneyFromFloat(12.345), currency: "AUD", want: "12.35", minor: 1235},
+		{m: MoneyFromFloat(-12.345), currency: "AUD", want: "-12.35", minor: -1235},
+		{m: MoneyFromFloat(12.344999), currency: "AUD", want: "12.34", minor: 1234},
+		{m: MoneyFromFloat(1234.5), currency: "JPY", want: "1235", minor: 1235},
+		{m: MoneyFromFloat(1.2345), currency: "KWD", want: "1.235", minor: 1235},
+		{m: NewMoney(1999, "AUD"), currency: "AUD", want: "19.99", minor: 1999},
+	}
+
+	for _, tt := range tests {
+		if got := tt.m.Format(tt.currency); got != tt.want {
+			t.Errorf("%v.Format(%s) = %s, want %s", tt.m, tt.currency, got, tt.want)
+		}
+		if got := tt.m.Minor(tt.currency); got != tt.minor {
+			t.Errorf("%v.Minor(%s) = %d, want %d", tt.m, tt.currency, got, tt.minor)
+		}
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	price := MoneyFromFloat(3.3)
+	if got := price.Mul(3); got != MoneyFromFloat(9.9) {
+		t.Errorf("Mul = %v, want 9.9", got)
+	}
+	if got := MoneyFromFloat(10).Div(3); got != 3333333 {
+		t.Errorf("Div = %d, want 3333333", got)
+	}
+	if got := MoneyFromFloat(10).MulFrac(1, 3); got != 3333333 {
+		t.Errorf("MulFrac = %d, want 3333333", got)
+	}
+	if got := MoneyFromFloat(1e30); got != Money(math.MaxInt64) {
+		t.Errorf("MoneyFromFloat(1e30) = %d, want clamped", got)
+	}
+	if got := MoneyFromFloat(math.NaN()); got != 0 {
+		t.Errorf("MoneyFromFloat(NaN) = %d, want 0", got)
+	}
+}
@@ -1,21 +1,109 @@
 package gokounta
 
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+//OrderStatus is the status of an order in Kounta
+type OrderStatus string
+
+//Order statuses used by Kounta
+const (
+	OrderStatusPending   OrderStatus = "PENDING"
+	OrderStatusOnHold    OrderStatus = "ON_HOLD"
+	OrderStatusComplete  OrderStatus = "COMPLETE"
+	OrderStatusCancelled OrderStatus = "CANCELLED"
+)
+
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending: {OrderStatusOnHold, OrderStatusComplete, OrderStatusCancelled},
+	OrderStatusOnHold:  {OrderStatusPending, OrderStatusComplete, OrderStatusCancelled},
+}
+
+// CanTransition will return true when an order can move from this status to the next
+func (s OrderStatus) CanTransition(next OrderStatus) bool {
+	for _, to := range orderTransitions[s] {
+		if to == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOpen will return true while an order can still be changed
+func (s OrderStatus) IsOpen() bool {
+	return s == OrderStatusPending || s == OrderStatusOnHold
+}
+
+//OrderType is whether an order is a sale, a refund or an exchange
+type OrderType string
+
+//Order types returned by Order.Type
+const (
+	OrderTypeSale     OrderType = "sale"
+	OrderTypeRefund   OrderType = "refund"
+	OrderTypeExchange OrderType = "exchange"
+)
+
 //Order defines a sale from Kounta
 type Order struct {
 	ID             int64         `json:"id"`
-	SaleDate       string        `json:"created_at"`
-	UpdateDate     string        `json:"updated_at"`
-	Status         string        `json:"status"`
+	SaleDate       Timestamp     `json:"created_at"`
+	UpdateDate     Timestamp     `json:"updated_at"`
+	Status         OrderStatus   `json:"status"`
 	Notes          string        `json:"notes"`
-	Total          float64       `json:"total"`
+	Total          Money         `json:"total"`
 	PriceVariation float64       `json:"price_variation"`
 	Customer       OrderCustomer `json:"customer"`
-	SiteID         float64       `json:"site_id"`
+	SiteID         int64         `json:"site_id"`
+	RegisterID     int64         `json:"register_id"`
+	Staff          Staff         `json:"staff_member"`
+	Fulfil         *OrderFulfil  `json:"fulfil,omitempty"`
+
+	OriginalOrderID int64  `json:"original_order_id,omitempty"`
+	RefundReason    string `json:"refund_reason,omitempty"`
 
 	Items    []OrderLine    `json:"lines"`
 	Payments []OrderPayment `json:"payments"`
 }
 
+//OrderQuery defines which pages of orders to fetch from Kounta
+type OrderQuery struct {
+	// Start is the Kounta start cursor to begin from
+	Start string
+	// MaxPages limits how many pages are followed, zero follows every page
+	MaxPages int
+	// CreatedFrom and CreatedTo limit orders to those created in the range when set
+	CreatedFrom Timestamp
+	CreatedTo   Timestamp
+}
+
+// values will return the url parameters for the query
+func (q OrderQuery) values() url.Values {
+	values := url.Values{}
+	if q.Start != "" {
+		values.Set("start", q.Start)
+	}
+	if !q.CreatedFrom.IsZero() {
+		values.Set("created_gte", q.CreatedFrom.String())
+	}
+	if !q.CreatedTo.IsZero() {
+		values.Set("created_lte", q.CreatedTo.String())
+	}
+	return values
+}
+
+//OrderFulfil defines how and when an order from Kounta is fulfilled
+type OrderFulfil struct {
+	Type    string    `json:"type,omitempty"`
+	Date    Timestamp `json:"date"`
+	Address string    `json:"address,omitempty"`
+	Phone   string    `json:"phone,omitempty"`
+	Notes   string    `json:"notes,omitempty"`
+}
+
 //OrderCustomer defines  line of an order from Kounta
 type OrderCustomer struct {
 	ID        int64  `json:"id"`
@@ -26,13 +114,18 @@ type OrderCustomer struct {
 //OrderLine defines a line of an order from Kounta
 type OrderLine struct {
 	Product        OrderLineProduct `json:"product"`
-	UnitPrice      float64          `json:"unit_price"`
-	UnitTax        float64          `json:"unit_tax"`
-	LineTotal      float64          `json:"line_total_ex_tax"`
-	LineTotalTax   float64          `json:"line_total_tax"`
+	UnitPrice      Money            `json:"unit_price"`
+	UnitTax        Money            `json:"unit_tax"`
+	LineTotal      Money            `json:"line_total_ex_tax"`
+	LineTotalTax   Money            `json:"line_total_tax"`
 	Quantity       float64          `json:"quantity"`
 	PriceVariation float64          `json:"price_variation"`
 	Modifiers      []int            `json:"modifiers"`
+	Notes          string           `json:"notes,omitempty"`
+	Taxes          []OrderLineTax   `json:"taxes,omitempty"`
+
+	// ResolvedModifiers is filled in by ModifierLookup.ResolveOrder
+	ResolvedModifiers []ResolvedModifier `json:"-"`
 }
 
 //OrderLineProduct defines a product within an order from Kounta
@@ -44,7 +137,8 @@ type OrderLineProduct struct {
 //OrderPayment defines a payment of an order from Kounta
 type OrderPayment struct {
 	Number int                `json:"number"`
-	Amount float64            `json:"amount"`
+	Amount Money              `json:"amount"`
+	Change Money              `json:"change"`
 	Method OrderPaymentMethod `json:"method"`
 }
 
@@ -54,12 +148,12 @@ type OrderPaymentMethod struct {
 	Name string `json:"name"`
 }
 
-// GetTotalTax will return the total tax for an order
-func (order *Order) GetTotalTax() float64 {
-	t := 0.00
+// TotalTax will return the exact total tax for an order
+func (order *Order) TotalTax() Money {
+	t := Money(0)
 	for _, item := range order.Items {
 		if item.PriceVariation > 0 && item.PriceVariation < 1 {
-			t += item.LineTotalTax * item.PriceVariation
+			t += item.LineTotalTax.Mul(item.PriceVariation)
 		} else {
 			t += item.LineTotalTax
 		}
@@ -67,11 +161,214 @@ func (order *Order) GetTotalTax() float64 {
 	return t
 }
 
-// CalculateDiscount will return the total discount for an order item
+// GetTotalTax will return the total tax for an order as a float, use TotalTax for an exact amount
+func (order *Order) GetTotalTax() float64 {
+	return order.TotalTax().Float64()
+}
+
+// Discount will return the exact discount the line's price variation takes off its gross.
+// Like TotalTax and AllocateVariations it treats LineTotal and LineTotalTax as the price before the variation.
+func (oi *OrderLine) Discount() Money {
+	if oi.PriceVariation > 0 && oi.PriceVariation < 1 {
+		gross := oi.LineTotal + oi.LineTotalTax
+		return gross - gross.Mul(oi.PriceVariation)
+	}
+	return 0
+}
+
+// CalculateDiscount will return the total discount for an order item as a float.
+// It keeps its original convention of treating LineTotal and LineTotalTax as the price after the variation,
+// so it does not agree with Discount and AllocateVariations, which use the price before the variation.
 func (oi *OrderLine) CalculateDiscount() float64 {
 	t := 0.00
 	if oi.PriceVariation > 0 && oi.PriceVariation < 1 {
-		return ((oi.LineTotal + oi.LineTotalTax) / oi.PriceVariation) - (oi.LineTotal + oi.LineTotalTax)
+		gross := (oi.LineTotal + oi.LineTotalTax).Float64()
+		return (gross / oi.PriceVariation) - gross
 	}
 	return t
 }
+
+// Type will classify the order as a sale, a refund or an exchange from the sign of its lines
+func (order *Order) Type() OrderType {
+	sold, refunded := false, false
+	for _, item := range order.Items {
+		if item.IsRefund() {
+			refunded = true
+		} else if item.Quantity > 0 {
+			sold = true
+		}
+	}
+
+	switch {
+	case sold && refunded:
+		return OrderTypeExchange
+	case refunded:
+		return OrderTypeRefund
+	case !sold && (order.Total < 0 || order.OriginalOrderID != 0):
+		return OrderTypeRefund
+	}
+	return OrderTypeSale
+}
+
+// IsRefund will return true when the order only gives money back
+func (order *Order) IsRefund() bool {
+	return order.Type() == OrderTypeRefund
+}
+
+// RefundedLines will return the lines of the order that were returned
+func (order *Order) RefundedLines() []OrderLine {
+	lines := []OrderLine{}
+	for _, item := range order.Items {
+		if item.IsRefund() {
+			lines = append(lines, item)
+		}
+	}
+	return lines
+}
+
+// IsRefund will return true when the line returns a product
+func (oi *OrderLine) IsRefund() bool {
+	return oi.Quantity < 0
+}
+
+// ValidateNew will check an order has everything Kounta needs before it is created
+func (order *Order) ValidateNew() error {
+	var problems []string
+
+	if order.SiteID <= 0 {
+		problems = append(problems, "missing site id")
+	}
+	if len(order.Items) == 0 {
+		problems = append(problems, "order has no lines")
+	}
+	for i, item := range order.Items {
+		if item.Product.ID <= 0 {
+			problems = append(problems, fmt.Sprintf("line %v missing product id", i+1))
+		}
+		if item.Quantity == 0 {
+			problems = append(problems, fmt.Sprintf("line %v has no quantity", i+1))
+		}
+	}
+	for i, payment := range order.Payments {
+		if payment.Method.ID <= 0 {
+			problems = append(problems, fmt.Sprintf("payment %v missing method id", i+1))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("Invalid Kounta Order: %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// orderRequest is the request struct for creating an order
+type orderRequest struct {
+	SiteID         int64                 `json:"site_id"`
+	CreatedAt      *Timestamp            `json:"created_at,omitempty"`
+	Status         OrderStatus           `json:"status,omitempty"`
+	Notes          string                `json:"notes,omitempty"`
+	CustomerID     int64                 `json:"customer_id,omitempty"`
+	PriceVariation float64               `json:"price_variation,omitempty"`
+	Fulfil         *OrderFulfil          `json:"fulfil,omitempty"`
+	Lines          []orderLineRequest    `json:"lines"`
+	Payments       []orderPaymentRequest `json:"payments,omitempty"`
+}
+
+// orderLineRequest is the request struct for a line of a new order
+type orderLineRequest struct {
+	ProductID      int64   `json:"product_id"`
+	Quantity       float64 `json:"quantity"`
+	UnitPrice      Money   `json:"unit_price,omitempty"`
+	PriceVariation float64 `json:"price_variation,omitempty"`
+	Modifiers      []int   `json:"modifiers,omitempty"`
+	Notes          string  `json:"notes,omitempty"`
+}
+
+// orderPaymentRequest is the request struct for a payment of a new order
+type orderPaymentRequest struct {
+	MethodID int64 `json:"method_id"`
+	Amount   Money `json:"amount"`
+}
+
+// orderUpdateRequest is the request struct for updating an open order
+type orderUpdateRequest struct {
+	Notes      string             `json:"notes"`
+	CustomerID int64              `json:"customer_id,omitempty"`
+	Lines      []orderLineRequest `json:"lines"`
+}
+
+// orderReferencePrefix marks the idempotency key reference CreateOrder adds to the order notes.
+// Kounta prints notes on kitchen tickets and receipts, so the reference is seen by staff and customers.
+const orderReferencePrefix = "ref:"
+
+// withOrderReference will add the reference line for the key to the notes
+func withOrderReference(notes string, key string) string {
+	if hasOrderReference(notes, key) {
+		return notes
+	}
+	if notes == "" {
+		return orderReferencePrefix + key
+	}
+	return notes + "\n" + orderReferencePrefix + key
+}
+
+// hasOrderReference will return true when the notes hold the reference line for the key
+func hasOrderReference(notes string, key string) bool {
+	for _, line := range strings.Split(notes, "\n") {
+		if strings.TrimSpace(line) == orderReferencePrefix+key {
+			return true
+		}
+	}
+	return false
+}
+
+func newOrderRequest(order Order) orderRequest {
+	req := orderRequest{
+		SiteID:         order.SiteID,
+		Status:         order.Status,
+		Notes:          order.Notes,
+		CustomerID:     order.Customer.ID,
+		PriceVariation: order.PriceVariation,
+		Fulfil:         order.Fulfil,
+	}
+
+	if !order.SaleDate.IsZero() {
+		req.CreatedAt = &order.SaleDate
+	}
+
+	req.Lines = newOrderLineRequests(order)
+
+	for _, payment := range order.Payments {
+		req.Payments = append(req.Payments, orderPaymentRequest{
+			MethodID: payment.Method.ID,
+			Amount:   payment.Amount,
+		})
+	}
+
+	return req
+}
+
+// newOrderUpdateRequest will build the request for updating an order, leaving out the site, status and payments
+func newOrderUpdateRequest(order Order) orderUpdateRequest {
+	return orderUpdateRequest{
+		Notes:      order.Notes,
+		CustomerID: order.Customer.ID,
+		Lines:      newOrderLineRequests(order),
+	}
+}
+
+// newOrderLineRequests will build the line requests for the items of an order
+func newOrderLineRequests(order Order) []orderLineRequest {
+	var lines []orderLineRequest
+	for _, item := range order.Items {
+		lines = append(lines, orderLineRequest{
+			ProductID:      item.Product.ID,
+			Quantity:       item.Quantity,
+			UnitPrice:      item.UnitPrice,
+			PriceVariation: item.PriceVariation,
+			Modifiers:      item.Modifiers,
+			Notes:          item.Notes,
+		})
+	}
+	return lines
+}
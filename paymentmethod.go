@@ -0,0 +1,23 @@
+package gokounta
+
+//PaymentMethod is the struct for a Kounta payment method
+type PaymentMethod struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	LedgerCode string `json:"ledger_code"`
+	IsActive   bool   `json:"is_active"`
+}
+
+//PaymentMethods is the struct for a list of PaymentMethod
+type PaymentMethods []PaymentMethod
+
+// Find will return the payment method with the id
+func (methods PaymentMethods) Find(id int64) (PaymentMethod, bool) {
+	for _, m := range methods {
+		if m.ID == id {
+			return m, true
+		}
+	}
+	return PaymentMethod{}, false
+}
@@ -0,0 +1,143 @@
+package gokounta
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var (
+	defaultPollInterval = time.Second * 15
+)
+
+//PendingOrderEventType is the kind of change seen in a site's pending queue
+type PendingOrderEventType string
+
+//Pending order event types
+const (
+	PendingOrderAdded   PendingOrderEventType = "added"
+	PendingOrderRemoved PendingOrderEventType = "removed"
+)
+
+//PendingOrderEvent is emitted when an order joins or leaves a site's pending queue
+type PendingOrderEvent struct {
+	Type    PendingOrderEventType
+	Company string
+	SiteID  string
+	Order   Order
+}
+
+//PendingOrderPoller watches the pending orders of a site
+type PendingOrderPoller struct {
+	Client   *Kounta
+	Company  string
+	SiteID   string
+	Interval time.Duration
+
+	mu      sync.Mutex
+	token   string
+	pending map[int64]Order
+}
+
+// NewPendingOrderPoller will create a poller for the pending orders of a site
+func NewPendingOrderPoller(client *Kounta, token string, company string, siteID string) *PendingOrderPoller {
+	return &PendingOrderPoller{
+		Client:   client,
+		Company:  company,
+		SiteID:   siteID,
+		Interval: defaultPollInterval,
+		token:    token,
+	}
+}
+
+// SetToken will replace the access token used by the poller, for use after a refresh
+func (p *PendingOrderPoller) SetToken(token string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.token = token
+}
+
+// Poll will fetch the pending queue once and return what changed since the last poll.
+// The first poll reports every order in the queue as added.
+func (p *PendingOrderPoller) Poll() ([]PendingOrderEvent, error) {
+	return p.PollContext(context.Background())
+}
+
+// PollContext will poll like Poll, stopping the fetch when ctx is done
+func (p *PendingOrderPoller) PollContext(ctx context.Context) ([]PendingOrderEvent, error) {
+	p.mu.Lock()
+	token := p.token
+	p.mu.Unlock()
+
+	orders, err := p.Client.QueryPendingOrdersContext(ctx, token, p.Company, p.SiteID, OrderQuery{})
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	current := make(map[int64]Order, len(orders))
+	events := []PendingOrderEvent{}
+
+	for _, order := range orders {
+		current[order.ID] = order
+		if _, ok := p.pending[order.ID]; !ok {
+			events = append(events, p.event(PendingOrderAdded, order))
+		}
+	}
+
+	for id, order := range p.pending {
+		if _, ok := current[id]; !ok {
+			events = append(events, p.event(PendingOrderRemoved, order))
+		}
+	}
+
+	p.pending = current
+	return events, nil
+}
+
+// Run will poll until the context is done, sending events to the channel.
+// Errors from a single poll are passed to onError when it is not nil and polling carries on.
+func (p *PendingOrderPoller) Run(ctx context.Context, events chan<- PendingOrderEvent, onError func(error)) error {
+	interval := p.Interval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		changes, err := p.PollContext(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil && onError != nil {
+			onError(err)
+		}
+
+		for _, e := range changes {
+			select {
+			case events <- e:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (p *PendingOrderPoller) event(t PendingOrderEventType, order Order) PendingOrderEvent {
+	return PendingOrderEvent{
+		Type:    t,
+		Company: p.Company,
+		SiteID:  p.SiteID,
+		Order:   order,
+	}
+}
@@ -0,0 +1,40 @@
+package gokounta
+
+//PriceList is the struct for a Kounta price list
+type PriceList struct {
+	ID       int              `json:"id,omitempty"`
+	Name     string           `json:"name"`
+	ParentID int              `json:"parent_id,omitempty"`
+	Sites    []int            `json:"sites,omitempty"`
+	Products []PriceListEntry `json:"products,omitempty"`
+}
+
+//PriceLists is the struct for a list of PriceList
+type PriceLists []PriceList
+
+//PriceListEntry is the price of a product on a Kounta price list
+type PriceListEntry struct {
+	ProductID int   `json:"id"`
+	UnitPrice Money `json:"unit_price"`
+}
+
+// Price will return the price of a product on the price list
+func (p *PriceList) Price(productID int) (Money, bool) {
+	for _, e := range p.Products {
+		if e.ProductID == productID {
+			return e.UnitPrice, true
+		}
+	}
+	return 0, false
+}
+
+// SetPrice will add or replace the price of a product on the price list
+func (p *PriceList) SetPrice(productID int, price Money) {
+	for i := range p.Products {
+		if p.Products[i].ProductID == productID {
+			p.Products[i].UnitPrice = price
+			return
+		}
+	}
+	p.Products = append(p.Products, PriceListEntry{ProductID: productID, UnitPrice: price})
+}
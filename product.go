@@ -6,6 +6,8 @@ type KountaProduct struct {
 	Name        string `json:"name"`
 	Code        string `json:"code"`
 	Description string `json:"description"`
+	UnitPrice   Money  `json:"unit_price"`
+	Categories  []int  `json:"categories,omitempty"`
 }
 
 //KountaProducts is a slice of KountaProduct
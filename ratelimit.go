@@ -0,0 +1,57 @@
+package gokounta
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+//RateLimiter paces requests to Kounta, Wait blocks until the next request may be sent or the context is done
+type RateLimiter interface {
+	Wait(ctx context.Context) error
+}
+
+//IntervalLimiter is a RateLimiter that spaces requests evenly
+type IntervalLimiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// NewRateLimiter will create a limiter allowing the given number of requests per second
+func NewRateLimiter(perSecond int) *IntervalLimiter {
+	if perSecond <= 0 {
+		perSecond = 1
+	}
+	return &IntervalLimiter{interval: time.Second / time.Duration(perSecond)}
+}
+
+// Wait will block until the next request slot, returning the context's error if it is done first
+func (l *IntervalLimiter) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	wait := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
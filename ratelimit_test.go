@@ -0,0 +1,30 @@
+package gokounta
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestIntervalLimiterWaitCancelled(t *testing.T) {
+	l := NewRateLimiter(1)
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("first Wait error %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := l.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Wait = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if waited := time.Since(start); waited > 500*time.Millisecond {
+		t.Errorf("Wait held for %v after the context was done", waited)
+	}
+
+	cancel()
+	if err := l.Wait(ctx); err == nil {
+		t.Errorf("Wait on a done context = nil, want error")
+	}
+}
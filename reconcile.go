@@ -0,0 +1,170 @@
+package gokounta
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultTolerance is the rounding difference allowed by Validate, one cent
+var DefaultTolerance = MoneyFromFloat(0.01)
+
+//FindingSeverity is how serious a reconciliation finding is
+type FindingSeverity string
+
+//Finding severities
+const (
+	SeverityWarning FindingSeverity = "warning"
+	SeverityError   FindingSeverity = "error"
+)
+
+//Finding codes reported by Reconcile
+const (
+	FindingLineTotal      = "line_total"
+	FindingLineTax        = "line_tax"
+	FindingPriceVariation = "price_variation"
+	FindingDiscount       = "discount"
+	FindingOrderTotal     = "order_total"
+	FindingUnderpaid      = "underpaid"
+	FindingOverpaid       = "overpaid"
+)
+
+//Finding is a single problem found when reconciling an order
+type Finding struct {
+	Code     string
+	Severity FindingSeverity
+	// Line is the index of the line in Order.Items, or -1 when the finding is for the whole order
+	Line     int
+	Expected Money
+	Actual   Money
+	Message  string
+}
+
+//Reconciliation is the result of checking an order adds up
+type Reconciliation struct {
+	OrderID  int64
+	Findings []Finding
+}
+
+//ReconcileError is returned by Validate when an order has error findings
+type ReconcileError struct {
+	Reconciliation
+}
+
+func (e *ReconcileError) Error() string {
+	msgs := []string{}
+	for _, f := range e.Errors() {
+		msgs = append(msgs, f.Message)
+	}
+	return fmt.Sprintf("Kounta Order %v does not reconcile: %s", e.OrderID, strings.Join(msgs, ", "))
+}
+
+// OK will return true when there are no error findings
+func (r Reconciliation) OK() bool {
+	return len(r.Errors()) == 0
+}
+
+// Errors will return only the error findings
+func (r Reconciliation) Errors() []Finding {
+	errs := []Finding{}
+	for _, f := range r.Findings {
+		if f.Severity == SeverityError {
+			errs = append(errs, f)
+		}
+	}
+	return errs
+}
+
+// Validate will reconcile the order with DefaultTolerance and return an error when it does not add up
+func (order *Order) Validate() error {
+	r := order.Reconcile(DefaultTolerance)
+	if r.OK() {
+		return nil
+	}
+	return &ReconcileError{Reconciliation: r}
+}
+
+// Reconcile will check line totals, tax, discounts, the order total and payments agree within the tolerance
+func (order *Order) Reconcile(tolerance Money) Reconciliation {
+	r := Reconciliation{OrderID: order.ID}
+
+	add := func(code string, severity FindingSeverity, line int, expected Money, actual Money, format string, args ...interface{}) {
+		r.Findings = append(r.Findings, Finding{
+			Code:     code,
+			Severity: severity,
+			Line:     line,
+			Expected: expected,
+			Actual:   actual,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	subtotal := Money(0)
+
+	for i, item := range order.Items {
+		expected := item.UnitPrice.Mul(item.Quantity)
+		expectedTax := item.UnitTax.Mul(item.Quantity)
+
+		// line totals are the price before the variation, so totals that already have it applied
+		// would have the discount taken a second time
+		if hasVariation(item.PriceVariation) && (expected-item.LineTotal).Abs() > tolerance &&
+			(expected.Mul(item.PriceVariation)-item.LineTotal).Abs() <= tolerance &&
+			(expectedTax.Mul(item.PriceVariation)-item.LineTotalTax).Abs() <= tolerance {
+			add(FindingDiscount, SeverityError, i, expected+expectedTax, item.LineTotal+item.LineTotalTax,
+				"line %v total %v already has its price variation %v applied", i+1, item.LineTotal+item.LineTotalTax, item.PriceVariation)
+		} else {
+			if (expected - item.LineTotal).Abs() > tolerance {
+				add(FindingLineTotal, SeverityError, i, expected, item.LineTotal,
+					"line %v total %v is not unit price x quantity %v", i+1, item.LineTotal, expected)
+			}
+			if (expectedTax - item.LineTotalTax).Abs() > tolerance {
+				add(FindingLineTax, SeverityError, i, expectedTax, item.LineTotalTax,
+					"line %v tax %v is not unit tax x quantity %v", i+1, item.LineTotalTax, expectedTax)
+			}
+		}
+
+		if item.PriceVariation < 0 {
+			add(FindingPriceVariation, SeverityError, i, 0, 0,
+				"line %v has a negative price variation %v", i+1, item.PriceVariation)
+		}
+
+		gross := item.LineTotal + item.LineTotalTax
+		if hasVariation(item.PriceVariation) {
+			gross = gross.Mul(item.PriceVariation)
+		}
+		subtotal += gross
+	}
+
+	if order.PriceVariation < 0 {
+		add(FindingPriceVariation, SeverityError, -1, 0, 0,
+			"order has a negative price variation %v", order.PriceVariation)
+	}
+
+	expectedTotal := subtotal
+	if hasVariation(order.PriceVariation) {
+		expectedTotal = subtotal.Mul(order.PriceVariation)
+	}
+	if (expectedTotal - order.Total).Abs() > tolerance {
+		add(FindingOrderTotal, SeverityError, -1, expectedTotal, order.Total,
+			"order total %v does not match lines plus tax %v", order.Total, expectedTotal)
+	}
+
+	paid := Money(0)
+	for _, payment := range order.Payments {
+		paid += payment.Amount - payment.Change
+	}
+
+	switch {
+	case order.Total-paid > tolerance:
+		severity := SeverityWarning
+		if order.Status == OrderStatusComplete {
+			severity = SeverityError
+		}
+		add(FindingUnderpaid, severity, -1, order.Total, paid,
+			"payments %v do not cover order total %v", paid, order.Total)
+	case paid-order.Total > tolerance:
+		add(FindingOverpaid, SeverityWarning, -1, order.Total, paid,
+			"payments %v are more than order total %v", paid, order.Total)
+	}
+
+	return r
+}
@@ -0,0 +1,127 @@
+package gokounta
+
+import "testing"
+
+func TestReconcile(t *testing.T) {
+	line := func(unit, unitTax, qty, pv float64) OrderLine {
+		return OrderLine{
+			UnitPrice:      money(unit),
+			UnitTax:        money(unitTax),
+			Quantity:       qty,
+			LineTotal:      money(unit * qty),
+			LineTotalTax:   money(unitTax * qty),
+			PriceVariation: pv,
+		}
+	}
+	paid := func(amount float64) []OrderPayment {
+		return []OrderPayment{{Amount: money(amount), Method: OrderPaymentMethod{ID: 1}}}
+	}
+
+	tests := []struct {
+		name   string
+		order  Order
+		codes  []string
+		errors int
+	}{
+		{
+			name:  "clean sale",
+			order: Order{Status: OrderStatusComplete, Total: money(22), Items: []OrderLine{line(10, 1, 2, 0)}, Payments: paid(22)},
+		},
+		{
+			name:  "discounted sale",
+			order: Order{Status: OrderStatusComplete, Total: money(9.9), Items: []OrderLine{line(10, 1, 1, 0.9)}, Payments: paid(9.9)},
+		},
+		{
+			name:  "discounted refund",
+			order: Order{Status: OrderStatusComplete, Total: money(-9.9), Items: []OrderLine{line(10, 1, -1, 0.9)}, Payments: paid(-9.9)},
+		},
+		{
+			name:  "order discount within a cent",
+			order: Order{Status: OrderStatusComplete, Total: money(10), PriceVariation: 0.5, Items: []OrderLine{line(9.09, 0.9, 2, 0)}, Payments: paid(10)},
+		},
+		{
+			name:   "line total does not match unit price",
+			order:  Order{Status: OrderStatusComplete, Total: money(12), Items: []OrderLine{{UnitPrice: money(10), Quantity: 1, LineTotal: money(12)}}, Payments: paid(12)},
+			codes:  []string{FindingLineTotal},
+			errors: 1,
+		},
+		{
+			name: "line total already discounted",
+			order: Order{Status: OrderStatusComplete, Total: money(8.91), Items: []OrderLine{
+				{UnitPrice: money(10), UnitTax: money(1), Quantity: 1, LineTotal: money(9), LineTotalTax: money(0.9), PriceVariation: 0.9},
+			}, Payments: paid(8.91)},
+			codes:  []string{FindingDiscount},
+			errors: 1,
+		},
+		{
+			name:   "discounted line total off by more than the variation",
+			order:  Order{Status: OrderStatusComplete, Total: money(7.2), Items: []OrderLine{{UnitPrice: money(10), Quantity: 1, LineTotal: money(8), PriceVariation: 0.9}}, Payments: paid(7.2)},
+			codes:  []string{FindingLineTotal},
+			errors: 1,
+		},
+		{
+			name:   "order total does not match lines",
+			order:  Order{Status: OrderStatusComplete, Total: money(30), Items: []OrderLine{line(10, 1, 2, 0)}, Payments: paid(30)},
+			codes:  []string{FindingOrderTotal},
+			errors: 1,
+		},
+		{
+			name:   "negative price variation",
+			order:  Order{Status: OrderStatusComplete, Total: money(11), Items: []OrderLine{line(10, 1, 1, -0.5)}, Payments: paid(11)},
+			codes:  []string{FindingPriceVariation},
+			errors: 1,
+		},
+		{
+			name:   "underpaid complete order",
+			order:  Order{Status: OrderStatusComplete, Total: money(11), Items: []OrderLine{line(10, 1, 1, 0)}, Payments: paid(5)},
+			codes:  []string{FindingUnderpaid},
+			errors: 1,
+		},
+		{
+			name:  "underpaid pending order is a warning",
+			order: Order{Status: OrderStatusPending, Total: money(11), Items: []OrderLine{line(10, 1, 1, 0)}},
+			codes: []string{FindingUnderpaid},
+		},
+		{
+			name:  "cash with change",
+			order: Order{Status: OrderStatusComplete, Total: money(11), Items: []OrderLine{line(10, 1, 1, 0)}, Payments: []OrderPayment{{Amount: money(20), Change: money(9)}}},
+		},
+		{
+			name:   "change leaves the order underpaid",
+			order:  Order{Status: OrderStatusComplete, Total: money(11), Items: []OrderLine{line(10, 1, 1, 0)}, Payments: []OrderPayment{{Amount: money(20), Change: money(10)}}},
+			codes:  []string{FindingUnderpaid},
+			errors: 1,
+		},
+		{
+			name:  "overpaid is a warning",
+			order: Order{Status: OrderStatusComplete, Total: money(11), Items: []OrderLine{line(10, 1, 1, 0)}, Payments: paid(20)},
+			codes: []string{FindingOverpaid},
+		},
+	}
+
+	for _, tt := range tests {
+		r := tt.order.Reconcile(DefaultTolerance)
+
+		codes := []string{}
+		for _, f := range r.Findings {
+			codes = append(codes, f.Code)
+		}
+		if len(codes) != len(tt.codes) {
+			t.Errorf("%s: findings %v, want %v", tt.name, r.Findings, tt.codes)
+			continue
+		}
+		for i := range codes {
+			if codes[i] != tt.codes[i] {
+				t.Errorf("%s: finding %d is %s, want %s", tt.name, i, codes[i], tt.codes[i])
+			}
+		}
+		if got := len(r.Errors()); got != tt.errors {
+			t.Errorf("%s: %d errors, want %d", tt.name, got, tt.errors)
+		}
+
+		err := tt.order.Validate()
+		if (err != nil) != (tt.errors > 0) {
+			t.Errorf("%s: Validate() = %v, want error %v", tt.name, err, tt.errors > 0)
+		}
+	}
+}
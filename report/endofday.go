@@ -0,0 +1,155 @@
+package report
+
+import (
+	"sort"
+
+	"github.com/albimcleod/gokounta"
+)
+
+//PaymentTotals is the takings of one payment method
+type PaymentTotals struct {
+	MethodID     int64
+	Method       string
+	Transactions int
+	// Payments are the amounts tendered, and Change is what was handed back
+	Payments gokounta.Money
+	Refunds  int
+	Refunded gokounta.Money
+	Change   gokounta.Money
+	Net      gokounta.Money
+}
+
+//EndOfDay is the payment takings of a site register for one business day
+type EndOfDay struct {
+	Day          string
+	SiteID       int64
+	RegisterID   int64
+	Transactions int
+	Net          gokounta.Money
+	Methods      map[int64]*PaymentTotals
+}
+
+//CashCheck compares counted cash with the cash expected in the drawer
+type CashCheck struct {
+	Expected gokounta.Money
+	Counted  gokounta.Money
+	// Variance is counted less expected, negative when the drawer is short
+	Variance gokounta.Money
+}
+
+//EndOfDayBuilder adds orders to end of day takings one at a time
+type EndOfDayBuilder struct {
+	opts  Options
+	days  map[endOfDayKey]*EndOfDay
+	order []endOfDayKey
+}
+
+type endOfDayKey struct {
+	day      string
+	site     int64
+	register int64
+}
+
+// NewEndOfDayBuilder will create an EndOfDayBuilder, using the options for business days
+func NewEndOfDayBuilder(opts Options) *EndOfDayBuilder {
+	return &EndOfDayBuilder{
+		opts: opts,
+		days: map[endOfDayKey]*EndOfDay{},
+	}
+}
+
+// EndOfDayReport will return the takings of the orders for each business day, site and register
+func EndOfDayReport(orders []gokounta.Order, opts Options) []*EndOfDay {
+	b := NewEndOfDayBuilder(opts)
+	for _, order := range orders {
+		b.Add(order)
+	}
+	return b.Days()
+}
+
+// Add will add the payments of an order to its business day, site and register
+func (b *EndOfDayBuilder) Add(order gokounta.Order) {
+	day, _ := b.opts.businessDay(order.SaleDate)
+	key := endOfDayKey{day: day, site: order.SiteID, register: order.RegisterID}
+
+	e, ok := b.days[key]
+	if !ok {
+		e = &EndOfDay{
+			Day:        day,
+			SiteID:     key.site,
+			RegisterID: key.register,
+			Methods:    map[int64]*PaymentTotals{},
+		}
+		b.days[key] = e
+		b.order = append(b.order, key)
+	}
+
+	for _, payment := range order.Payments {
+		m, ok := e.Methods[payment.Method.ID]
+		if !ok {
+			m = &PaymentTotals{MethodID: payment.Method.ID, Method: payment.Method.Name}
+			e.Methods[payment.Method.ID] = m
+		}
+
+		m.Transactions++
+		e.Transactions++
+
+		if payment.Amount < 0 {
+			m.Refunds++
+			m.Refunded -= payment.Amount
+		} else {
+			m.Payments += payment.Amount
+		}
+		m.Change += payment.Change
+
+		net := payment.Amount - payment.Change
+		m.Net += net
+		e.Net += net
+	}
+}
+
+// Days will return the takings built so far, sorted by day, site and register
+func (b *EndOfDayBuilder) Days() []*EndOfDay {
+	keys := append([]endOfDayKey{}, b.order...)
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].day != keys[j].day {
+			return keys[i].day < keys[j].day
+		}
+		if keys[i].site != keys[j].site {
+			return keys[i].site < keys[j].site
+		}
+		return keys[i].register < keys[j].register
+	})
+
+	days := make([]*EndOfDay, 0, len(keys))
+	for _, k := range keys {
+		days = append(days, b.days[k])
+	}
+	return days
+}
+
+// SortedMethods will return the payment method totals sorted by method id
+func (e *EndOfDay) SortedMethods() []*PaymentTotals {
+	methods := make([]*PaymentTotals, 0, len(e.Methods))
+	for _, m := range e.Methods {
+		methods = append(methods, m)
+	}
+	sort.Slice(methods, func(i, j int) bool {
+		return methods[i].MethodID < methods[j].MethodID
+	})
+	return methods
+}
+
+// CheckCash will compare counted cash with the opening float plus the net cash taken
+func (e *EndOfDay) CheckCash(cashMethodID int64, openingFloat gokounta.Money, counted gokounta.Money) CashCheck {
+	expected := openingFloat
+	if m, ok := e.Methods[cashMethodID]; ok {
+		expected += m.Net
+	}
+
+	return CashCheck{
+		Expected: expected,
+		Counted:  counted,
+		Variance: counted - expected,
+	}
+}
@@ -0,0 +1,232 @@
+// Package report builds sales summaries from Kounta orders
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/albimcleod/gokounta"
+)
+
+// dayFormat is the layout used for business day keys
+const dayFormat = "2006-01-02"
+
+// defaultCurrency is used to round line amounts when no currency is given
+const defaultCurrency = gokounta.Currency("AUD")
+
+//Options controls how orders are grouped
+type Options struct {
+	// DayStart is the hour a business day starts, so late trade before it counts to the day before
+	DayStart int
+	// Location is used for days and hours, nil keeps the offset each order was sent with
+	Location *time.Location
+	// ProductCategory looks up the category of a product, nil leaves every product uncategorised
+	ProductCategory func(productID int64) (gokounta.Category, bool)
+	// ModifierPrices holds the gross unit price of modifiers, used when allocating order discounts
+	ModifierPrices map[int]gokounta.Money
+	// Currency is used to round line amounts to minor units, defaulting to AUD
+	Currency gokounta.Currency
+}
+
+//Totals is the sales totals of a group of orders, with refunds netted against sales
+type Totals struct {
+	Orders   int
+	Refunds  int
+	Quantity float64
+	Gross    gokounta.Money
+	Net      gokounta.Money
+	Tax      gokounta.Money
+	Discount gokounta.Money
+	// Refunded is the gross given back on refunded lines, already taken off Gross
+	Refunded gokounta.Money
+}
+
+//Row is the totals for a single key of a grouping, such as one product
+type Row struct {
+	Key  string
+	Name string
+	Totals
+}
+
+//Group is a set of rows keyed by a grouping such as product
+type Group map[string]*Row
+
+//Summary is the sales summary of a stream of orders
+type Summary struct {
+	Totals
+	ByDay           Group
+	BySite          Group
+	ByHour          Group
+	ByProduct       Group
+	ByCategory      Group
+	ByStaff         Group
+	ByPaymentMethod Group
+}
+
+//Builder adds orders to a summary one at a time
+type Builder struct {
+	opts    Options
+	summary Summary
+}
+
+// NewBuilder will create a Builder with empty groups
+func NewBuilder(opts Options) *Builder {
+	return &Builder{
+		opts: opts,
+		summary: Summary{
+			ByDay:           Group{},
+			BySite:          Group{},
+			ByHour:          Group{},
+			ByProduct:       Group{},
+			ByCategory:      Group{},
+			ByStaff:         Group{},
+			ByPaymentMethod: Group{},
+		},
+	}
+}
+
+// Summarize will build a summary from every order sent on the channel until it is closed
+func Summarize(orders <-chan gokounta.Order, opts Options) Summary {
+	b := NewBuilder(opts)
+	for order := range orders {
+		b.Add(order)
+	}
+	return b.Summary()
+}
+
+// SummarizeOrders will build a summary from a slice of orders
+func SummarizeOrders(orders []gokounta.Order, opts Options) Summary {
+	b := NewBuilder(opts)
+	for _, order := range orders {
+		b.Add(order)
+	}
+	return b.Summary()
+}
+
+// Summary will return the summary built so far
+func (b *Builder) Summary() Summary {
+	return b.summary
+}
+
+// Add will add an order to every grouping of the summary
+func (b *Builder) Add(order gokounta.Order) {
+	s := &b.summary
+	allocs := order.AllocateVariations(b.opts.ModifierPrices, b.opts.currency())
+
+	orderTotals := Totals{Orders: 1}
+	if order.IsRefund() {
+		orderTotals = Totals{Refunds: 1}
+	}
+	seen := map[*Row]bool{}
+
+	for i, item := range order.Items {
+		a := allocs[i]
+		line := Totals{
+			Quantity: item.Quantity,
+			Gross:    a.Gross,
+			Net:      a.Net,
+			Tax:      a.Tax,
+			Discount: a.Discount,
+		}
+		if item.IsRefund() {
+			line.Refunded = -a.Gross
+		}
+		orderTotals.add(line)
+
+		product := s.ByProduct.row(strconv.FormatInt(item.Product.ID, 10), item.Product.Name)
+		product.addLine(line, item.IsRefund(), seen)
+
+		category := gokounta.Category{}
+		if b.opts.ProductCategory != nil {
+			category, _ = b.opts.ProductCategory(item.Product.ID)
+		}
+		s.ByCategory.row(strconv.Itoa(category.ID), category.Name).addLine(line, item.IsRefund(), seen)
+	}
+
+	s.Totals.add(orderTotals)
+
+	day, t := b.opts.businessDay(order.SaleDate)
+
+	s.ByDay.row(day, day).add(orderTotals)
+	s.BySite.row(strconv.FormatInt(order.SiteID, 10), "").add(orderTotals)
+	s.ByHour.row(fmt.Sprintf("%02d", t.Hour()), fmt.Sprintf("%02d:00", t.Hour())).add(orderTotals)
+	s.ByStaff.row(strconv.Itoa(order.Staff.ID), strings.TrimSpace(order.Staff.FirstName+" "+order.Staff.LastName)).add(orderTotals)
+
+	for _, payment := range order.Payments {
+		row := s.ByPaymentMethod.row(strconv.FormatInt(payment.Method.ID, 10), payment.Method.Name)
+		// change handed back is not part of the takings
+		net := payment.Amount - payment.Change
+		paid := Totals{Gross: net}
+		if net < 0 {
+			paid.Refunded = -net
+		}
+		row.addLine(paid, order.IsRefund(), seen)
+	}
+}
+
+func (o Options) currency() gokounta.Currency {
+	if o.Currency == "" {
+		return defaultCurrency
+	}
+	return o.Currency
+}
+
+// businessDay will return the business day of a date and the date in the report location
+func (o Options) businessDay(ts gokounta.Timestamp) (string, time.Time) {
+	t := ts.Time
+	if o.Location != nil {
+		t = t.In(o.Location)
+	}
+	return t.Add(-time.Duration(o.DayStart) * time.Hour).Format(dayFormat), t
+}
+
+// Rows will return the rows of the group sorted by key
+func (g Group) Rows() []*Row {
+	rows := make([]*Row, 0, len(g))
+	for _, r := range g {
+		rows = append(rows, r)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].Key < rows[j].Key
+	})
+	return rows
+}
+
+func (g Group) row(key string, name string) *Row {
+	r, ok := g[key]
+	if !ok {
+		r = &Row{Key: key, Name: name}
+		g[key] = r
+	}
+	if r.Name == "" {
+		r.Name = name
+	}
+	return r
+}
+
+// addLine will add a line to the row, counting the order once however many of its lines land here
+func (r *Row) addLine(line Totals, refund bool, seen map[*Row]bool) {
+	if !seen[r] {
+		if refund {
+			line.Refunds = 1
+		} else {
+			line.Orders = 1
+		}
+		seen[r] = true
+	}
+	r.add(line)
+}
+
+func (t *Totals) add(o Totals) {
+	t.Orders += o.Orders
+	t.Refunds += o.Refunds
+	t.Quantity += o.Quantity
+	t.Gross += o.Gross
+	t.Net += o.Net
+	t.Tax += o.Tax
+	t.Discount += o.Discount
+	t.Refunded += o.Refunded
+}
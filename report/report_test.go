@@ -0,0 +1,209 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/albimcleod/gokounta"
+)
+
+func money(f float64) gokounta.Money {
+	return gokounta.MoneyFromFloat(f)
+}
+
+// line will return a line of the product for qty units of net plus 10% tax
+func line(product int64, qty float64, net float64) gokounta.OrderLine {
+	return gokounta.OrderLine{
+		Product:      gokounta.OrderLineProduct{ID: product},
+		Quantity:     qty,
+		UnitPrice:    money(net),
+		UnitTax:      money(net / 10),
+		LineTotal:    money(net * qty),
+		LineTotalTax: money(net * qty / 10),
+	}
+}
+
+func at(day int, hour int) gokounta.Timestamp {
+	return gokounta.NewTimestamp(time.Date(2024, 1, day, hour, 30, 0, 0, time.UTC))
+}
+
+func TestSummaryBuckets(t *testing.T) {
+	orders := []gokounta.Order{
+		{ID: 1, SaleDate: at(2, 3), Items: []gokounta.OrderLine{line(1, 1, 10)}},
+		{ID: 2, SaleDate: at(2, 10), Items: []gokounta.OrderLine{line(1, 1, 10)}},
+		{ID: 3, SaleDate: at(2, 23), Items: []gokounta.OrderLine{line(1, 1, 10)}},
+	}
+
+	tests := []struct {
+		name  string
+		opts  Options
+		days  map[string]int
+		hours map[string]int
+	}{
+		{
+			name:  "midnight day start",
+			opts:  Options{},
+			days:  map[string]int{"2024-01-02": 3},
+			hours: map[string]int{"03": 1, "10": 1, "23": 1},
+		},
+		{
+			name:  "early trade counts to the day before",
+			opts:  Options{DayStart: 5},
+			days:  map[string]int{"2024-01-01": 1, "2024-01-02": 2},
+			hours: map[string]int{"03": 1, "10": 1, "23": 1},
+		},
+		{
+			name:  "location moves the day and hour",
+			opts:  Options{DayStart: 5, Location: time.FixedZone("AEDT", 11*60*60)},
+			days:  map[string]int{"2024-01-02": 2, "2024-01-03": 1},
+			hours: map[string]int{"14": 1, "21": 1, "10": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		s := SummarizeOrders(orders, tt.opts)
+
+		if len(s.ByDay) != len(tt.days) {
+			t.Errorf("%s: days %v, want %v", tt.name, s.ByDay.Rows(), tt.days)
+		}
+		for day, n := range tt.days {
+			if r, ok := s.ByDay[day]; !ok || r.Orders != n {
+				t.Errorf("%s: day %s = %+v, want %d orders", tt.name, day, r, n)
+			}
+		}
+
+		if len(s.ByHour) != len(tt.hours) {
+			t.Errorf("%s: hours %v, want %v", tt.name, s.ByHour.Rows(), tt.hours)
+		}
+		for hour, n := range tt.hours {
+			if r, ok := s.ByHour[hour]; !ok || r.Orders != n {
+				t.Errorf("%s: hour %s = %+v, want %d orders", tt.name, hour, r, n)
+			}
+		}
+	}
+}
+
+func TestSummaryNetsRefunds(t *testing.T) {
+	tests := []struct {
+		name     string
+		orders   []gokounta.Order
+		want     Totals
+		products map[string]Totals
+	}{
+		{
+			name:   "sale",
+			orders: []gokounta.Order{{Items: []gokounta.OrderLine{line(1, 2, 10)}}},
+			want:   Totals{Orders: 1, Quantity: 2, Gross: money(22), Net: money(20), Tax: money(2)},
+		},
+		{
+			name: "refund nets against the sale",
+			orders: []gokounta.Order{
+				{Items: []gokounta.OrderLine{line(1, 2, 10)}},
+				{Items: []gokounta.OrderLine{line(1, -1, 10)}},
+			},
+			want: Totals{Orders: 1, Refunds: 1, Quantity: 1, Gross: money(11), Net: money(10), Tax: money(1), Refunded: money(11)},
+			products: map[string]Totals{
+				"1": {Orders: 1, Refunds: 1, Quantity: 1, Gross: money(11), Net: money(10), Tax: money(1), Refunded: money(11)},
+			},
+		},
+		{
+			name:   "exchange counts as a sale with the returned line netted",
+			orders: []gokounta.Order{{Items: []gokounta.OrderLine{line(2, 1, 20), line(1, -1, 10)}}},
+			want:   Totals{Orders: 1, Gross: money(11), Net: money(10), Tax: money(1), Refunded: money(11)},
+			products: map[string]Totals{
+				"1": {Refunds: 1, Quantity: -1, Gross: money(-11), Net: money(-10), Tax: money(-1), Refunded: money(11)},
+				"2": {Orders: 1, Quantity: 1, Gross: money(22), Net: money(20), Tax: money(2)},
+			},
+		},
+		{
+			name:   "discounted refund nets its discount",
+			orders: []gokounta.Order{{PriceVariation: 0.9, Items: []gokounta.OrderLine{line(1, -1, 10)}}},
+			want:   Totals{Refunds: 1, Quantity: -1, Gross: money(-9.9), Net: money(-9), Tax: money(-0.9), Discount: money(-1.1), Refunded: money(9.9)},
+		},
+	}
+
+	for _, tt := range tests {
+		s := SummarizeOrders(tt.orders, Options{})
+		if s.Totals != tt.want {
+			t.Errorf("%s: totals %+v, want %+v", tt.name, s.Totals, tt.want)
+		}
+		for key, want := range tt.products {
+			if r, ok := s.ByProduct[key]; !ok || r.Totals != want {
+				t.Errorf("%s: product %s = %+v, want %+v", tt.name, key, r, want)
+			}
+		}
+	}
+}
+
+func TestSummaryPaymentMethods(t *testing.T) {
+	cash := gokounta.OrderPaymentMethod{ID: 1, Name: "Cash"}
+	card := gokounta.OrderPaymentMethod{ID: 2, Name: "Card"}
+
+	orders := []gokounta.Order{
+		{Items: []gokounta.OrderLine{line(1, 1, 10)}, Payments: []gokounta.OrderPayment{
+			{Amount: money(5), Method: cash},
+			{Amount: money(6), Method: card},
+		}},
+		{Items: []gokounta.OrderLine{line(1, 2, 10)}, Payments: []gokounta.OrderPayment{{Amount: money(22), Method: card}}},
+		{Items: []gokounta.OrderLine{line(1, -1, 10)}, Payments: []gokounta.OrderPayment{{Amount: money(-11), Method: cash}}},
+		{Items: []gokounta.OrderLine{line(1, 1, 10)}, Payments: []gokounta.OrderPayment{{Amount: money(20), Change: money(9), Method: cash}}},
+	}
+
+	s := SummarizeOrders(orders, Options{})
+
+	want := map[string]Totals{
+		"1": {Orders: 2, Refunds: 1, Gross: money(5), Refunded: money(11)},
+		"2": {Orders: 2, Gross: money(28)},
+	}
+	for key, w := range want {
+		if r, ok := s.ByPaymentMethod[key]; !ok || r.Totals != w {
+			t.Errorf("payment method %s = %+v, want %+v", key, r, w)
+		}
+	}
+}
+
+func TestEndOfDayCheckCash(t *testing.T) {
+	cash := gokounta.OrderPaymentMethod{ID: 1, Name: "Cash"}
+	card := gokounta.OrderPaymentMethod{ID: 2, Name: "Card"}
+
+	orders := []gokounta.Order{
+		{SiteID: 10, SaleDate: at(2, 9), Payments: []gokounta.OrderPayment{{Amount: money(20), Change: money(4.5), Method: cash}}},
+		{SiteID: 10, SaleDate: at(2, 12), Payments: []gokounta.OrderPayment{{Amount: money(30), Method: card}}},
+		{SiteID: 10, SaleDate: at(2, 15), Payments: []gokounta.OrderPayment{{Amount: money(-5.5), Method: cash}}},
+		{SiteID: 10, SaleDate: at(3, 9), Payments: []gokounta.OrderPayment{{Amount: money(50), Method: cash}}},
+	}
+
+	days := EndOfDayReport(orders, Options{})
+	if len(days) != 2 || days[0].Day != "2024-01-02" {
+		t.Fatalf("days %+v, want 2024-01-02 and 2024-01-03", days)
+	}
+
+	day := days[0]
+	if m := day.Methods[1]; m.Payments != money(20) || m.Change != money(4.5) || m.Refunded != money(5.5) || m.Net != money(10) {
+		t.Errorf("cash totals %+v, want 20 paid, 4.5 change, 5.5 refunded and 10 net", m)
+	}
+	if day.Net != money(40) || day.Transactions != 3 {
+		t.Errorf("day net %v over %d transactions, want 40 over 3", day.Net, day.Transactions)
+	}
+
+	tests := []struct {
+		name     string
+		counted  float64
+		variance float64
+	}{
+		{name: "balanced", counted: 110, variance: 0},
+		{name: "short", counted: 105, variance: -5},
+		{name: "over", counted: 112.5, variance: 2.5},
+	}
+
+	for _, tt := range tests {
+		c := day.CheckCash(cash.ID, money(100), money(tt.counted))
+		if c.Expected != money(110) || c.Variance != money(tt.variance) {
+			t.Errorf("%s: expected %v variance %v, want 110 and %v", tt.name, c.Expected, c.Variance, tt.variance)
+		}
+	}
+
+	if c := day.CheckCash(99, money(100), money(100)); c.Expected != money(100) || c.Variance != 0 {
+		t.Errorf("unused method check %+v, want the float expected", c)
+	}
+}
@@ -8,15 +8,15 @@ import (
 
 //Shift is the struct for a Kounta Shift
 type Shift struct {
-	StartedAt  string       `json:"started_at"`
-	FinishedAt string       `json:"finished_at"`
+	StartedAt  Timestamp    `json:"started_at"`
+	FinishedAt Timestamp    `json:"finished_at"`
 	Staff      Staff        `json:"staff_member"`
 	Breaks     []ShiftBreak `json:"breaks"`
 }
 
 type ShiftBreak struct {
-	StartedAt  string `json:"started_at"`
-	FinishedAt string `json:"finished_at"`
+	StartedAt  Timestamp `json:"started_at"`
+	FinishedAt Timestamp `json:"finished_at"`
 }
 
 //FieldMap is required for binding
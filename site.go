@@ -1,9 +1,24 @@
 package gokounta
 
+import "time"
+
 //Site is the struct for a Kounta Site
 type Site struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Timezone string `json:"timezone"`
+}
+
+// LocalTime will return a Kounta date in the site's timezone, falling back to the date's own offset
+func (s *Site) LocalTime(t Timestamp) time.Time {
+	if s.Timezone == "" {
+		return t.Time
+	}
+	local, err := t.InZone(s.Timezone)
+	if err != nil {
+		return t.Time
+	}
+	return local
 }
 
 //Sites is the struct for a list of Site
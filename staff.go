@@ -2,11 +2,77 @@ package gokounta
 
 //Staff is the struct for a Kounta Staff
 type Staff struct {
-	ID        int    `json:"id"`
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
-	Email     string `json:"primary_email_address"`
+	ID         int    `json:"id,omitempty"`
+	FirstName  string `json:"first_name"`
+	LastName   string `json:"last_name"`
+	Email      string `json:"primary_email_address"`
+	Phone      string `json:"phone,omitempty"`
+	Mobile     string `json:"mobile,omitempty"`
+	IsActive   bool   `json:"is_active"`
+	HasPIN     bool   `json:"has_pin"`
+	PINVisible bool   `json:"pin_visible"`
+	Roles      []Role `json:"roles,omitempty"`
+	Sites      []int  `json:"sites,omitempty"`
+}
+
+//StaffUpdate is the request struct for creating or updating a staff member, nil fields are left unchanged.
+//Point Roles or Sites at an empty slice to remove every role or all site access.
+type StaffUpdate struct {
+	FirstName  *string `json:"first_name,omitempty"`
+	LastName   *string `json:"last_name,omitempty"`
+	Email      *string `json:"primary_email_address,omitempty"`
+	Phone      *string `json:"phone,omitempty"`
+	Mobile     *string `json:"mobile,omitempty"`
+	IsActive   *bool   `json:"is_active,omitempty"`
+	PINVisible *bool   `json:"pin_visible,omitempty"`
+	Roles      *[]Role `json:"roles,omitempty"`
+	Sites      *[]int  `json:"sites,omitempty"`
 }
 
 //Staffs is the struct for a list of Staff
 type Staffs []Staff
+
+//Role is the struct for a Kounta staff role or permission group
+type Role struct {
+	ID          int      `json:"id"`
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+//Roles is the struct for a list of Role
+type Roles []Role
+
+// HasSite will return true when the staff member can access the site
+func (s *Staff) HasSite(siteID int) bool {
+	for _, id := range s.Sites {
+		if id == siteID {
+			return true
+		}
+	}
+	return false
+}
+
+// newStaffRequest will return the request for creating the staff member, new staff are always active
+func newStaffRequest(s Staff) StaffUpdate {
+	active := true
+	req := StaffUpdate{
+		FirstName:  &s.FirstName,
+		LastName:   &s.LastName,
+		Email:      &s.Email,
+		IsActive:   &active,
+		PINVisible: &s.PINVisible,
+	}
+	if s.Roles != nil {
+		req.Roles = &s.Roles
+	}
+	if s.Sites != nil {
+		req.Sites = &s.Sites
+	}
+	if s.Phone != "" {
+		req.Phone = &s.Phone
+	}
+	if s.Mobile != "" {
+		req.Mobile = &s.Mobile
+	}
+	return req
+}
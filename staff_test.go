@@ -0,0 +1,38 @@
+package gokounta
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestStaffUpdateJSON(t *testing.T) {
+	noRoles := []Role{}
+	noSites := []int{}
+	visible := false
+
+	tests := []struct {
+		name   string
+		update StaffUpdate
+		want   string
+	}{
+		{name: "empty update", update: StaffUpdate{}, want: `{}`},
+		{name: "remove every role and site", update: StaffUpdate{Roles: &noRoles, Sites: &noSites}, want: `{"roles":[],"sites":[]}`},
+		{name: "hide pin", update: StaffUpdate{PINVisible: &visible}, want: `{"pin_visible":false}`},
+		{
+			name:   "create",
+			update: newStaffRequest(Staff{FirstName: "Ann", LastName: "Lee", Email: "ann@example.com", PINVisible: true, Sites: []int{10}}),
+			want:   `{"first_name":"Ann","last_name":"Lee","primary_email_address":"ann@example.com","is_active":true,"pin_visible":true,"sites":[10]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		b, err := json.Marshal(tt.update)
+		if err != nil {
+			t.Errorf("%s: Marshal error %v", tt.name, err)
+			continue
+		}
+		if string(b) != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, b, tt.want)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package gokounta
+
+import (
+	"sort"
+	"strings"
+)
+
+//OrderLineTax defines a tax charged on a line of an order from Kounta
+type OrderLineTax struct {
+	ID   int64   `json:"id"`
+	Code string  `json:"code"`
+	Name string  `json:"name"`
+	Rate float64 `json:"rate"`
+}
+
+//Tax is the struct for a Kounta tax
+type Tax struct {
+	ID         int64   `json:"id"`
+	Code       string  `json:"code"`
+	Name       string  `json:"name"`
+	Type       string  `json:"type"`
+	Rate       float64 `json:"rate"`
+	Inclusive  bool    `json:"inclusive"`
+	LedgerCode string  `json:"ledger_code"`
+}
+
+//Taxes is the struct for a list of Tax
+type Taxes []Tax
+
+// Find will return the tax with the code
+func (taxes Taxes) Find(code string) (Tax, bool) {
+	for _, t := range taxes {
+		if t.Code == code {
+			return t, true
+		}
+	}
+	return Tax{}, false
+}
+
+//TaxRateSummary is the net, tax and gross of everything charged at one tax rate
+type TaxRateSummary struct {
+	Code   string
+	Name   string
+	Rate   float64
+	Exempt bool
+	Net    Money
+	Tax    Money
+	Gross  Money
+}
+
+//TaxBreakdown is the tax of one or more orders split by tax rate
+type TaxBreakdown struct {
+	Rates   []TaxRateSummary
+	Taxable Money
+	Exempt  Money
+	Net     Money
+	Tax     Money
+	Gross   Money
+}
+
+// Tax codes used for lines without a taxes list
+const (
+	// exemptTaxCode is the code used for lines without tax
+	exemptTaxCode = "EXEMPT"
+	// unknownTaxCode is the code used for taxed lines that do not say which taxes apply
+	unknownTaxCode = "UNKNOWN"
+)
+
+// inferredRateDigits is how many decimal places the effective rate of the unknown tax code is rounded to
+const inferredRateDigits = 4
+
+// TaxBreakdown will return the tax of the order split by tax rate.
+// The amounts are the ones AllocateVariations gives each line, so line and order price variations
+// and surcharges are included and the gross sums to the order total.
+func (order *Order) TaxBreakdown(modifierPrices map[int]Money, currency Currency) TaxBreakdown {
+	b := TaxBreakdown{}
+	b.addOrder(*order, modifierPrices, currency)
+	return b
+}
+
+// TaxBreakdownForOrders will return the tax of all the orders split by tax rate
+func TaxBreakdownForOrders(orders []Order, modifierPrices map[int]Money, currency Currency) TaxBreakdown {
+	b := TaxBreakdown{}
+	for _, order := range orders {
+		b.addOrder(order, modifierPrices, currency)
+	}
+	return b
+}
+
+// Add will merge another breakdown into this one
+func (b *TaxBreakdown) Add(o TaxBreakdown) {
+	for _, r := range o.Rates {
+		b.add(r)
+	}
+}
+
+// Rate will return the summary for a tax code
+func (b *TaxBreakdown) Rate(code string) (TaxRateSummary, bool) {
+	for _, r := range b.Rates {
+		if r.Code == code {
+			return r, true
+		}
+	}
+	return TaxRateSummary{}, false
+}
+
+// TaxCode will return the code and combined rate of the taxes on the line.
+// Taxed lines without a taxes list share the UNKNOWN code, with the rate inferred from their amounts.
+func (oi *OrderLine) TaxCode() (string, string, float64) {
+	if len(oi.Taxes) == 0 {
+		if oi.LineTotalTax.IsZero() {
+			return exemptTaxCode, "Tax exempt", 0
+		}
+		return unknownTaxCode, "Unknown rate", effectiveRate(oi.LineTotal, oi.LineTotalTax)
+	}
+
+	codes := make([]string, 0, len(oi.Taxes))
+	names := make([]string, 0, len(oi.Taxes))
+	rate := 0.0
+	for _, t := range oi.Taxes {
+		codes = append(codes, t.Code)
+		names = append(names, t.Name)
+		rate += t.Rate
+	}
+	return strings.Join(codes, "+"), strings.Join(names, " + "), rate
+}
+
+func (b *TaxBreakdown) addOrder(order Order, modifierPrices map[int]Money, currency Currency) {
+	for _, a := range order.AllocateVariations(modifierPrices, currency) {
+		code, name, rate := order.Items[a.Line].TaxCode()
+
+		b.add(TaxRateSummary{
+			Code:   code,
+			Name:   name,
+			Rate:   rate,
+			Exempt: code == exemptTaxCode,
+			Net:    a.Net,
+			Tax:    a.Tax,
+			Gross:  a.Gross,
+		})
+	}
+}
+
+func (b *TaxBreakdown) add(r TaxRateSummary) {
+	if r.Exempt {
+		b.Exempt += r.Net
+	} else {
+		b.Taxable += r.Net
+	}
+	b.Net += r.Net
+	b.Tax += r.Tax
+	b.Gross += r.Gross
+
+	for i := range b.Rates {
+		if b.Rates[i].Code == r.Code {
+			b.Rates[i].Net += r.Net
+			b.Rates[i].Tax += r.Tax
+			b.Rates[i].Gross += r.Gross
+			if r.Code == unknownTaxCode {
+				b.Rates[i].Rate = effectiveRate(b.Rates[i].Net, b.Rates[i].Tax)
+			}
+			return
+		}
+	}
+
+	b.Rates = append(b.Rates, r)
+	sort.SliceStable(b.Rates, func(i, j int) bool {
+		return b.Rates[i].Code < b.Rates[j].Code
+	})
+}
+
+// effectiveRate will return tax over net rounded to inferredRateDigits, or zero when there is no net
+func effectiveRate(net Money, tax Money) float64 {
+	if net.IsZero() {
+		return 0
+	}
+	return tax.MulFrac(1000000, net).RoundTo(inferredRateDigits).Float64()
+}
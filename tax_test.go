@@ -0,0 +1,93 @@
+package gokounta
+
+import "testing"
+
+func TestTaxBreakdownInferredRates(t *testing.T) {
+	line := func(net, tax float64) OrderLine {
+		return OrderLine{LineTotal: MoneyFromFloat(net), LineTotalTax: MoneyFromFloat(tax), Quantity: 1}
+	}
+
+	order := Order{Items: []OrderLine{
+		line(9.09, 0.91),
+		line(4.09, 0.41),
+		line(0.95, 0.10),
+		line(5, 0),
+	}}
+
+	b := order.TaxBreakdown(nil, "AUD")
+	if len(b.Rates) != 2 {
+		t.Fatalf("got %d rates, want exempt and unknown: %+v", len(b.Rates), b.Rates)
+	}
+
+	unknown, ok := b.Rate(unknownTaxCode)
+	if !ok {
+		t.Fatalf("missing %s rate in %+v", unknownTaxCode, b.Rates)
+	}
+	if unknown.Net != MoneyFromFloat(14.13) || unknown.Tax != MoneyFromFloat(1.42) {
+		t.Errorf("unknown net %v tax %v, want 14.13 and 1.42", unknown.Net, unknown.Tax)
+	}
+	if unknown.Rate != 0.1005 {
+		t.Errorf("unknown rate %v, want 0.1005", unknown.Rate)
+	}
+
+	exempt, _ := b.Rate(exemptTaxCode)
+	if !exempt.Exempt || exempt.Net != MoneyFromFloat(5) {
+		t.Errorf("exempt %+v, want net 5", exempt)
+	}
+}
+
+func TestTaxBreakdownListedTaxes(t *testing.T) {
+	gst := OrderLineTax{ID: 1, Code: "GST", Name: "GST", Rate: 0.1}
+	order := Order{Items: []OrderLine{
+		{LineTotal: MoneyFromFloat(10), LineTotalTax: MoneyFromFloat(1), Taxes: []OrderLineTax{gst}},
+		{LineTotal: MoneyFromFloat(0.95), LineTotalTax: MoneyFromFloat(0.1), Taxes: []OrderLineTax{gst}},
+	}}
+
+	b := order.TaxBreakdown(nil, "AUD")
+	if len(b.Rates) != 1 || b.Rates[0].Code != "GST" || b.Rates[0].Rate != 0.1 {
+		t.Fatalf("rates %+v, want one GST rate", b.Rates)
+	}
+	if b.Tax != MoneyFromFloat(1.1) || b.Taxable != MoneyFromFloat(10.95) {
+		t.Errorf("tax %v taxable %v, want 1.1 and 10.95", b.Tax, b.Taxable)
+	}
+}
+
+func TestTaxBreakdownPriceVariations(t *testing.T) {
+	gst := OrderLineTax{ID: 1, Code: "GST", Name: "GST", Rate: 0.1}
+	line := func(net, tax float64, pv float64) OrderLine {
+		return OrderLine{LineTotal: MoneyFromFloat(net), LineTotalTax: MoneyFromFloat(tax), Quantity: 1, PriceVariation: pv, Taxes: []OrderLineTax{gst}}
+	}
+
+	tests := []struct {
+		name  string
+		order Order
+		tax   Money
+		gross Money
+	}{
+		{
+			name:  "order discount",
+			order: Order{PriceVariation: 0.9, Items: []OrderLine{line(10, 1, 0), line(20, 2, 0)}},
+			tax:   MoneyFromFloat(2.7),
+			gross: MoneyFromFloat(29.7),
+		},
+		{
+			name:  "order surcharge",
+			order: Order{PriceVariation: 1.1, Items: []OrderLine{line(10, 1, 0), line(20, 2, 0)}},
+			tax:   MoneyFromFloat(3.3),
+			gross: MoneyFromFloat(36.3),
+		},
+		{
+			name:  "line discount and order total",
+			order: Order{Total: MoneyFromFloat(29), Items: []OrderLine{line(10, 1, 0.5), line(20, 2, 0)}},
+			tax:   MoneyFromFloat(2.64),
+			gross: MoneyFromFloat(29),
+		},
+	}
+
+	for _, tt := range tests {
+		b := tt.order.TaxBreakdown(nil, "AUD")
+		if b.Tax != tt.tax || b.Gross != tt.gross || b.Net+b.Tax != b.Gross {
+			t.Errorf("%s: net %v tax %v gross %v, want tax %v gross %v", tt.name, b.Net, b.Tax, b.Gross, tt.tax, tt.gross)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package gokounta
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// kountaTimeFormat is the layout Kounta expects for dates sent to the api
+const kountaTimeFormat = "2006-01-02T15:04:05-07:00"
+
+// timestampFormats are the layouts Kounta uses for dates it returns
+var timestampFormats = []string{
+	time.RFC3339Nano,
+	kountaTimeFormat,
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05-07:00",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+//Timestamp is a Kounta date which keeps the offset it was sent with
+type Timestamp struct {
+	time.Time
+}
+
+// NewTimestamp will create a Timestamp from a time
+func NewTimestamp(t time.Time) Timestamp {
+	return Timestamp{Time: t}
+}
+
+// ParseTimestamp will read a date in any of the formats Kounta returns.
+// Dates without an offset are read as UTC.
+func ParseTimestamp(s string) (Timestamp, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range timestampFormats {
+		if t, err := time.Parse(layout, s); err == nil {
+			return Timestamp{Time: t}, nil
+		}
+	}
+	return Timestamp{}, fmt.Errorf("Invalid Kounta date %q", s)
+}
+
+// InZone will return the time in the named timezone, such as a site's Australia/Sydney
+func (t Timestamp) InZone(zone string) (time.Time, error) {
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.Time.In(loc), nil
+}
+
+// String will return the date in the format Kounta expects
+func (t Timestamp) String() string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(kountaTimeFormat)
+}
+
+// MarshalJSON will write the date in the format Kounta expects, or null when unset
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte("\"" + t.String() + "\""), nil
+}
+
+// UnmarshalJSON will read the date from any of the formats Kounta returns
+func (t *Timestamp) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), "\"")
+	if s == "" || s == "null" {
+		*t = Timestamp{}
+		return nil
+	}
+
+	v, err := ParseTimestamp(s)
+	if err != nil {
+		return err
+	}
+	*t = v
+	return nil
+}